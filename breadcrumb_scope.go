@@ -0,0 +1,34 @@
+package logrus_sentry
+
+import (
+	"context"
+	"net/http"
+)
+
+// NewBreadcrumbContext returns a copy of ctx which has its own buffer of
+// breadcrumbs. The entries logged with the context, e.g. by
+// logger.WithContext(ctx), record breadcrumbs into the buffer, and the events
+// logged with the context have only the breadcrumbs in it.
+// The buffer belongs to a new scope, which copies the scope of ctx if any.
+func NewBreadcrumbContext(ctx context.Context) context.Context {
+	scope := NewScope()
+	if parent, ok := ScopeFromContext(ctx); ok {
+		scope = parent.cloneData()
+	}
+	return WithScope(ctx, scope)
+}
+
+// BreadcrumbMiddleware wraps an http.Handler so that each request has its own
+// buffer of breadcrumbs in its context.
+func BreadcrumbMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(NewBreadcrumbContext(r.Context())))
+	})
+}
+
+func breadcrumbBufferFromContext(ctx context.Context) (*breadcrumbBuffer, bool) {
+	if scope, ok := ScopeFromContext(ctx); ok {
+		return &scope.breadcrumbs, true
+	}
+	return nil, false
+}
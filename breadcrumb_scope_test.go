@@ -0,0 +1,54 @@
+package logrus_sentry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreadcrumbScope(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.BreadcrumbConfiguration.Enable = true
+		logger.Hooks.Add(hook)
+
+		var ctx context.Context
+		handler := BreadcrumbMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx = r.Context()
+			logger.WithContext(ctx).Info("request")
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		logger.Info("global")
+
+		logger.WithContext(ctx).Error("request failed")
+		packet := <-pch
+		if assert.Len(t, packet.Breadcrumbs.Values, 1) {
+			assert.Equal(t, "request", packet.Breadcrumbs.Values[0].Message)
+		}
+
+		logger.WithContext(context.Background()).Error("failed")
+		packet = <-pch
+		if assert.Len(t, packet.Breadcrumbs.Values, 1) {
+			assert.Equal(t, "global", packet.Breadcrumbs.Values[0].Message)
+		}
+	})
+}
+
+// breadcrumbBufferFor returns the buffer of ctx, or the global buffer of the
+// hook when it has no buffer.
+func (hook *SentryHook) breadcrumbBufferFor(ctx context.Context) *breadcrumbBuffer {
+	if b, ok := breadcrumbBufferFromContext(ctx); ok {
+		return b
+	}
+	return &hook.root().breadcrumbs
+}
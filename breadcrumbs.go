@@ -0,0 +1,284 @@
+package logrus_sentry
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultBreadcrumbCategory = "logrus"
+
+// Breadcrumb is a trail of events which happened before an event.
+type Breadcrumb struct {
+	// the type of the breadcrumb, e.g. "default", "http", "query" or "error"
+	Type     string                 `json:"type,omitempty"`
+	Category string                 `json:"category,omitempty"`
+	Message  string                 `json:"message,omitempty"`
+	Level    raven.Severity         `json:"level,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	// sent as float seconds since the epoch
+	Timestamp time.Time `json:"-"`
+}
+
+// Value is the former breadcrumb type, which Breadcrumbs no longer holds.
+// Convert it with Breadcrumb.
+//
+// Deprecated: use Breadcrumb.
+type Value struct {
+	Timestamp int64       `json:"timestamp"`
+	Type      string      `json:"type"`
+	Message   string      `json:"message"`
+	Category  string      `json:"category"`
+	Level     string      `json:"string"`
+	Data      interface{} `json:"data"`
+}
+
+// Breadcrumb converts v into a Breadcrumb. Data which isn't a map is put under
+// the "data" key.
+func (v Value) Breadcrumb() Breadcrumb {
+	b := Breadcrumb{
+		Type:     v.Type,
+		Category: v.Category,
+		Message:  v.Message,
+		Level:    raven.Severity(v.Level),
+	}
+	if v.Timestamp != 0 {
+		b.Timestamp = time.Unix(v.Timestamp, 0)
+	}
+	switch data := v.Data.(type) {
+	case nil:
+	case map[string]interface{}:
+		b.Data = data
+	default:
+		b.Data = map[string]interface{}{"data": data}
+	}
+	return b
+}
+
+// MarshalJSON encodes the timestamp as float seconds, which sentry expects.
+func (b Breadcrumb) MarshalJSON() ([]byte, error) {
+	type breadcrumb Breadcrumb
+	v := struct {
+		breadcrumb
+		Timestamp float64 `json:"timestamp,omitempty"`
+	}{breadcrumb: breadcrumb(b)}
+	if !b.Timestamp.IsZero() {
+		v.Timestamp = unixSeconds(b.Timestamp)
+	}
+	return json.Marshal(v)
+}
+
+// unixSeconds returns t as float seconds since the epoch.
+func unixSeconds(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/float64(time.Second)
+}
+
+// UnmarshalJSON decodes the float timestamp encoded by MarshalJSON.
+func (b *Breadcrumb) UnmarshalJSON(data []byte) error {
+	type breadcrumb Breadcrumb
+	v := struct {
+		*breadcrumb
+		Timestamp float64 `json:"timestamp"`
+	}{breadcrumb: (*breadcrumb)(b)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Timestamp != 0 {
+		// float seconds are precise to about microseconds
+		sec, frac := math.Modf(v.Timestamp)
+		usec := math.Floor(frac*float64(time.Second/time.Microsecond) + 0.5)
+		b.Timestamp = time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+	}
+	return nil
+}
+
+// Breadcrumbs is the interface of breadcrumbs in the packet.
+type Breadcrumbs struct {
+	Values []Breadcrumb `json:"values"`
+}
+
+// Class returns the key of breadcrumbs in the packet.
+func (b *Breadcrumbs) Class() string {
+	return "breadcrumbs"
+}
+
+// BreadcrumbConfiguration allows for configuring the breadcrumbs recorded from
+// the log entries which are not sent as events.
+// It must be configured before the hook is added to the logger, as logrus
+// takes the levels of hooks when they are added.
+type BreadcrumbConfiguration struct {
+	// whether log entries should be recorded as breadcrumbs
+	Enable bool
+	// the levels of the log entries recorded as breadcrumbs
+	Levels []logrus.Level
+	// the maximum number of breadcrumbs attached to an event. older ones are
+	// dropped
+	MaxBreadcrumbs int
+}
+
+// breadcrumbBuffer is a ring buffer of breadcrumbs.
+type breadcrumbBuffer struct {
+	mu     sync.Mutex
+	values []Breadcrumb
+	// the index to write the next value
+	next int
+	full bool
+}
+
+// add records a breadcrumb, dropping the oldest one when there are already
+// size breadcrumbs.
+func (b *breadcrumbBuffer) add(v Breadcrumb, size int) {
+	if size <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.values) != size {
+		b.resize(size)
+	}
+	b.values[b.next] = v
+	b.next++
+	if b.next == size {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// resize keeps the most recent breadcrumbs which fit in the new size.
+func (b *breadcrumbBuffer) resize(size int) {
+	values := b.ordered()
+	if len(values) > size {
+		values = values[len(values)-size:]
+	}
+	b.values = make([]Breadcrumb, size)
+	b.next = copy(b.values, values)
+	b.full = b.next == size
+	if b.full {
+		b.next = 0
+	}
+}
+
+// ordered returns the breadcrumbs from the oldest one. It must be called
+// with the lock held.
+func (b *breadcrumbBuffer) ordered() []Breadcrumb {
+	if !b.full {
+		return append([]Breadcrumb(nil), b.values[:b.next]...)
+	}
+	values := make([]Breadcrumb, 0, len(b.values))
+	values = append(values, b.values[b.next:]...)
+	return append(values, b.values[:b.next]...)
+}
+
+// restore replaces the breadcrumbs with values, ordered from the oldest one.
+func (b *breadcrumbBuffer) restore(values []Breadcrumb) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the buffer is full of values, and it's resized by the next add
+	b.values = append([]Breadcrumb(nil), values...)
+	b.next = 0
+	b.full = len(b.values) != 0
+}
+
+// snapshot returns a copy of the breadcrumbs from the oldest one.
+func (b *breadcrumbBuffer) snapshot() []Breadcrumb {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ordered()
+}
+
+// isEventLevel reports whether entries of the level are sent as events.
+func (hook *SentryHook) isEventLevel(level logrus.Level) bool {
+	for _, l := range hook.levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// recordBreadcrumb records the entry as a breadcrumb.
+func (hook *SentryHook) recordBreadcrumb(entry *logrus.Entry) {
+	conf := &hook.BreadcrumbConfiguration
+	if !conf.Enable {
+		return
+	}
+	recorded := false
+	for _, l := range conf.Levels {
+		if l == entry.Level {
+			recorded = true
+			break
+		}
+	}
+	if !recorded {
+		return
+	}
+
+	df := newDataField(entry.Data)
+	category := defaultBreadcrumbCategory
+	if hook.loggerName != "" {
+		category = hook.loggerName
+	}
+	if logger, ok := df.getLogger(); ok {
+		category = logger
+	}
+	if crumbs, ok := df.getBreadcrumbs(); ok {
+		for _, b := range withTimestamp(crumbs, entry.Time) {
+			hook.addBreadcrumb(entry.Context, b)
+		}
+	}
+	b := Breadcrumb{
+		Timestamp: entry.Time,
+		Type:      "default",
+		Category:  category,
+		Message:   entry.Message,
+		Level:     severityMap[entry.Level],
+	}
+	if data := hook.formatExtraData(df); len(data) != 0 {
+		b.Data = data
+	}
+	hook.addBreadcrumb(entry.Context, b)
+}
+
+// AddBreadcrumb records a breadcrumb into the buffer of ctx, or the global
+// buffer when ctx has no buffer or is nil. The current time is used when its
+// timestamp is zero. The global buffer ignores it unless breadcrumbs are
+// enabled.
+func (hook *SentryHook) AddBreadcrumb(ctx context.Context, b Breadcrumb) {
+	if b.Timestamp.IsZero() {
+		b.Timestamp = time.Now()
+	}
+	hook.addBreadcrumb(ctx, b)
+}
+
+// addBreadcrumb records a breadcrumb into the buffer of ctx. The global buffer
+// is used only when breadcrumbs are enabled, while the buffer of a scope always
+// records them, the same as Scope.AddBreadcrumb.
+func (hook *SentryHook) addBreadcrumb(ctx context.Context, b Breadcrumb) {
+	conf := &hook.BreadcrumbConfiguration
+	if buf, ok := breadcrumbBufferFromContext(ctx); ok {
+		buf.add(b, conf.MaxBreadcrumbs)
+		return
+	}
+	if !conf.Enable {
+		return
+	}
+	hook.root().breadcrumbs.add(b, conf.MaxBreadcrumbs)
+}
+
+// withTimestamp sets t to the breadcrumbs whose timestamp is zero.
+func withTimestamp(crumbs []Breadcrumb, t time.Time) []Breadcrumb {
+	for i := range crumbs {
+		if crumbs[i].Timestamp.IsZero() {
+			crumbs[i].Timestamp = t
+		}
+	}
+	return crumbs
+}
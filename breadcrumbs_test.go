@@ -0,0 +1,237 @@
+package logrus_sentry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreadcrumbBuffer(t *testing.T) {
+	a := assert.New(t)
+
+	messages := func(values []Breadcrumb) []string {
+		var result []string
+		for _, v := range values {
+			result = append(result, v.Message)
+		}
+		return result
+	}
+
+	b := &breadcrumbBuffer{}
+	a.Empty(b.snapshot())
+
+	for i := 0; i < 2; i++ {
+		b.add(Breadcrumb{Message: fmt.Sprint(i)}, 3)
+	}
+	a.Equal([]string{"0", "1"}, messages(b.snapshot()))
+
+	for i := 2; i < 5; i++ {
+		b.add(Breadcrumb{Message: fmt.Sprint(i)}, 3)
+	}
+	a.Equal([]string{"2", "3", "4"}, messages(b.snapshot()))
+
+	// shrinking keeps the most recent ones
+	b.add(Breadcrumb{Message: "5"}, 2)
+	a.Equal([]string{"4", "5"}, messages(b.snapshot()))
+
+	// growing keeps all of them
+	b.add(Breadcrumb{Message: "6"}, 4)
+	a.Equal([]string{"4", "5", "6"}, messages(b.snapshot()))
+
+	b.add(Breadcrumb{Message: "7"}, 0)
+	a.Equal([]string{"4", "5", "6"}, messages(b.snapshot()))
+}
+
+func TestBreadcrumbLevels(t *testing.T) {
+	a := assert.New(t)
+
+	hook, err := NewSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	a.NoError(err)
+	a.Equal([]logrus.Level{logrus.ErrorLevel}, hook.Levels())
+
+	hook.BreadcrumbConfiguration.Enable = true
+	a.Equal([]logrus.Level{
+		logrus.ErrorLevel,
+		logrus.DebugLevel,
+		logrus.InfoLevel,
+		logrus.WarnLevel,
+	}, hook.Levels())
+}
+
+func TestBreadcrumbConfiguration(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		logger.Level = logrus.DebugLevel
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.BreadcrumbConfiguration.Enable = true
+		hook.BreadcrumbConfiguration.MaxBreadcrumbs = 3
+		hook.StacktraceConfiguration.Enable = true
+		hook.StacktraceConfiguration.IncludeErrorBreadcrumb = true
+		logger.Hooks.Add(hook)
+
+		logger.Debug("dropped")
+		logger.WithField("logger", "db").Info("connected")
+		logger.WithField("user_id", 13).Warn("slow query")
+		logger.WithField("error", errors.New("failed")).Error("request failed")
+
+		packet := <-pch
+		values := packet.Breadcrumbs.Values
+		if !assert.Len(t, values, 3) {
+			return
+		}
+
+		assert.Equal(t, "connected", values[0].Message)
+		assert.Equal(t, "db", values[0].Category)
+		assert.Equal(t, raven.INFO, values[0].Level)
+		assert.Nil(t, values[0].Data)
+
+		assert.Equal(t, "slow query", values[1].Message)
+		assert.Equal(t, defaultBreadcrumbCategory, values[1].Category)
+		assert.Equal(t, raven.WARNING, values[1].Level)
+		assert.Equal(t, map[string]interface{}{"user_id": float64(13)}, values[1].Data)
+
+		assert.Equal(t, "error", values[2].Type)
+		assert.Equal(t, "failed", values[2].Message)
+	})
+}
+
+func TestBreadcrumbJSON(t *testing.T) {
+	a := assert.New(t)
+
+	b := Breadcrumb{
+		Type:      "http",
+		Category:  "http",
+		Level:     raven.WARNING,
+		Data:      map[string]interface{}{"status_code": float64(404)},
+		Timestamp: time.Unix(1500000000, 250000000),
+	}
+	data, err := json.Marshal(b)
+	a.NoError(err)
+	a.JSONEq(`{"type":"http","category":"http","level":"warning","data":{"status_code":404},"timestamp":1500000000.25}`, string(data))
+
+	var decoded Breadcrumb
+	a.NoError(json.Unmarshal(data, &decoded))
+	a.Equal(b.Data, decoded.Data)
+	a.Equal(b.Level, decoded.Level)
+	a.True(b.Timestamp.Equal(decoded.Timestamp))
+
+	data, err = json.Marshal(Breadcrumb{Message: "no timestamp"})
+	a.NoError(err)
+	a.JSONEq(`{"message":"no timestamp"}`, string(data))
+}
+
+func TestValueBreadcrumb(t *testing.T) {
+	a := assert.New(t)
+
+	b := Value{
+		Timestamp: 1500000000,
+		Type:      "error",
+		Message:   "failed",
+		Level:     "error",
+		Data:      "detail",
+	}.Breadcrumb()
+	a.Equal(Breadcrumb{
+		Type:      "error",
+		Message:   "failed",
+		Level:     raven.ERROR,
+		Data:      map[string]interface{}{"data": "detail"},
+		Timestamp: time.Unix(1500000000, 0),
+	}, b)
+
+	data := map[string]interface{}{"user_id": 13}
+	a.Equal(data, Value{Data: data}.Breadcrumb().Data)
+	a.True(Value{}.Breadcrumb().Timestamp.IsZero())
+}
+
+func TestAddBreadcrumb(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.BreadcrumbConfiguration.Enable = true
+		logger.Hooks.Add(hook)
+
+		hook.AddBreadcrumb(nil, Breadcrumb{Category: "auth", Message: "logged in"})
+		logger.WithField("breadcrumbs", Breadcrumb{Message: "recorded with info"}).Info("info")
+
+		entryTime := time.Unix(1500000000, 0)
+		logger.WithTime(entryTime).WithField("breadcrumbs", []*Breadcrumb{
+			{Category: "ui", Message: "clicked"},
+			nil,
+		}).Error("failed")
+
+		packet := <-pch
+		values := packet.Breadcrumbs.Values
+		if !assert.Len(t, values, 4) {
+			return
+		}
+		assert.Equal(t, "logged in", values[0].Message)
+		assert.False(t, values[0].Timestamp.IsZero())
+		assert.Equal(t, "recorded with info", values[1].Message)
+		assert.Equal(t, "info", values[2].Message)
+		assert.Nil(t, values[2].Data)
+		assert.Equal(t, "clicked", values[3].Message)
+		assert.True(t, entryTime.Equal(values[3].Timestamp))
+		assert.NotContains(t, packet.Extra, "breadcrumbs")
+	})
+}
+
+func TestAddBreadcrumbDisabled(t *testing.T) {
+	a := assert.New(t)
+
+	hook, err := NewSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	a.NoError(err)
+
+	ctx := NewBreadcrumbContext(context.Background())
+	hook.AddBreadcrumb(ctx, Breadcrumb{Message: "scoped"})
+	hook.AddBreadcrumb(nil, Breadcrumb{Message: "global"})
+
+	values := hook.breadcrumbBufferFor(ctx).snapshot()
+	if a.Len(values, 1) {
+		a.Equal("scoped", values[0].Message)
+	}
+	a.Empty(hook.breadcrumbs.snapshot(), "the global buffer should ignore breadcrumbs unless enabled")
+}
+
+func TestMaxBreadcrumbsWithField(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.BreadcrumbConfiguration.Enable = true
+		hook.BreadcrumbConfiguration.MaxBreadcrumbs = 2
+		hook.StacktraceConfiguration.IncludeErrorBreadcrumb = true
+		logger.Hooks.Add(hook)
+
+		logger.Info("first")
+		logger.Info("second")
+		logger.WithError(errors.New("failed")).WithField("breadcrumbs", Breadcrumb{Message: "field"}).Error("error")
+
+		packet := <-pch
+		values := packet.Breadcrumbs.Values
+		if assert.Len(t, values, 2) {
+			assert.Equal(t, "field", values[0].Message)
+			assert.Equal(t, "error", values[1].Type)
+		}
+	})
+}
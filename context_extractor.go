@@ -0,0 +1,66 @@
+package logrus_sentry
+
+import (
+	"context"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+)
+
+// AddContextExtractor adds a function extracting fields from the context of
+// entries, e.g. request IDs, tenant IDs or principals.
+// The extracted fields are processed the same as the fields of entries, so
+// "user_id" becomes the user, "tags" (raven.Tags or map[string]string) become
+// tags, and others become extra data. The fields of entries take precedence
+// over them, and extracted tags are merged with the tags of entries.
+func (hook *SentryHook) AddContextExtractor(fn func(ctx context.Context) map[string]interface{}) {
+	hook.contextExtractors = append(hook.contextExtractors, fn)
+}
+
+// extractContext returns the fields of entry merged with the fields extracted
+// from its context.
+func (hook *SentryHook) extractContext(entry *logrus.Entry) logrus.Fields {
+	if entry.Context == nil || len(hook.contextExtractors) == 0 {
+		return entry.Data
+	}
+
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	for _, fn := range hook.contextExtractors {
+		for k, v := range fn(entry.Context) {
+			if k == fieldTags {
+				data[k] = mergeTags(data[k], v)
+				continue
+			}
+			if _, ok := data[k]; !ok {
+				data[k] = v
+			}
+		}
+	}
+	return data
+}
+
+// mergeTags returns the tags of both values, keeping the tags of current for
+// the same keys.
+func mergeTags(current, extracted interface{}) interface{} {
+	tags, ok := toTags(current)
+	if current != nil && !ok {
+		return current
+	}
+	added, ok := toTags(extracted)
+	if !ok {
+		return current
+	}
+
+	// copied so the tags of the entry are not modified
+	result := make(raven.Tags, len(tags), len(tags)+len(added))
+	copy(result, tags)
+	for _, tag := range added {
+		if !hasTag(result, tag.Key) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
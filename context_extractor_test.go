@@ -0,0 +1,84 @@
+package logrus_sentry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDKey struct{}
+
+func TestMergeTags(t *testing.T) {
+	a := assert.New(t)
+
+	entryTags := raven.Tags{{Key: "a", Value: "entry"}}
+	merged := mergeTags(entryTags, map[string]string{"b": "ctx", "a": "ctx"})
+	a.Equal(raven.Tags{{Key: "a", Value: "entry"}, {Key: "b", Value: "ctx"}}, merged)
+	a.Len(entryTags, 1, "tags of the entry should not be modified")
+
+	a.Equal(raven.Tags{{Key: "b", Value: "ctx"}}, mergeTags(nil, raven.Tags{{Key: "b", Value: "ctx"}}))
+	a.Equal("invalid", mergeTags("invalid", raven.Tags{{Key: "b", Value: "ctx"}}))
+	a.Equal(entryTags, mergeTags(entryTags, "invalid"))
+}
+
+func TestToTagsCopy(t *testing.T) {
+	a := assert.New(t)
+
+	tags := make(raven.Tags, 1, 2)
+	tags[0] = raven.Tag{Key: "a", Value: "caller"}
+	merged := mergeTags(tags, map[string]string{"b": "ctx"})
+	a.Equal(raven.Tags{{Key: "a", Value: "caller"}, {Key: "b", Value: "ctx"}}, merged)
+	a.Equal(raven.Tag{}, tags[:2][1], "the spare capacity of the caller should not be written")
+
+	copied, _ := toTags(tags)
+	copied[0].Value = "modified"
+	a.Equal("caller", tags[0].Value)
+}
+
+func TestContextExtractor(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.AddContextExtractor(func(ctx context.Context) map[string]interface{} {
+			id, ok := ctx.Value(requestIDKey{}).(string)
+			if !ok {
+				return nil
+			}
+			return map[string]interface{}{
+				"tags":       map[string]string{"request_id": id, "tenant": "ctx"},
+				"user_id":    "13",
+				"request_id": id,
+				"order":      "ctx",
+			}
+		})
+		logger.Hooks.Add(hook)
+
+		ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+		logger.WithContext(ctx).WithFields(logrus.Fields{
+			"tags":  raven.Tags{{Key: "tenant", Value: "entry"}},
+			"order": "entry",
+		}).Error("failed")
+
+		packet := <-pch
+		assert.Equal(t, raven.Tags{
+			{Key: "tenant", Value: "entry"},
+			{Key: "request_id", Value: "req-1"},
+		}, packet.Tags)
+		assert.Equal(t, "req-1", packet.Extra["request_id"])
+		assert.Equal(t, "entry", packet.Extra["order"])
+		assert.Equal(t, "13", packet.User.ID)
+
+		logger.Error("without context")
+		packet = <-pch
+		assert.Empty(t, packet.Tags)
+		assert.NotContains(t, packet.Extra, "request_id")
+	})
+}
@@ -1,7 +1,10 @@
 package logrus_sentry
 
 import (
+	"bytes"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/getsentry/raven-go"
 	"github.com/sirupsen/logrus"
@@ -14,7 +17,16 @@ const (
 	fieldServerName  = "server_name"
 	fieldTags        = "tags"
 	fieldHTTPRequest = "http_request"
+	fieldHTTPBody    = "http_request_body"
 	fieldUser        = "user"
+	fieldBreadcrumbs = "breadcrumbs"
+
+	fieldUserPrefix  = "user_"
+	fieldUserName    = "user_name"
+	fieldUserEmail   = "user_email"
+	fieldUserID      = "user_id"
+	fieldUserIP      = "user_ip"
+	fieldUserSegment = "user_segment"
 )
 
 type dataField struct {
@@ -55,13 +67,37 @@ func (d *dataField) getServerName() (string, bool) {
 }
 
 func (d *dataField) getTags() (raven.Tags, bool) {
-	if tags, ok := d.data[fieldTags].(raven.Tags); ok {
+	if tags, ok := toTags(d.data[fieldTags]); ok {
 		d.omitList[fieldTags] = struct{}{}
 		return tags, true
 	}
 	return nil, false
 }
 
+// toTags returns the tags of raven.Tags or map[string]string, which are
+// sorted by key.
+func toTags(value interface{}) (raven.Tags, bool) {
+	switch v := value.(type) {
+	case raven.Tags:
+		// the tags are appended to, so the slice of the caller is copied
+		tags := make(raven.Tags, len(v))
+		copy(tags, v)
+		return tags, true
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		tags := make(raven.Tags, len(keys))
+		for i, k := range keys {
+			tags[i] = raven.Tag{Key: k, Value: v[k]}
+		}
+		return tags, true
+	}
+	return nil, false
+}
+
 func (d *dataField) getFingerprint() ([]string, bool) {
 	if fingerprint, ok := d.data[fieldFingerprint].([]string); ok {
 		d.omitList[fieldFingerprint] = struct{}{}
@@ -70,6 +106,32 @@ func (d *dataField) getFingerprint() ([]string, bool) {
 	return nil, false
 }
 
+// getBreadcrumbs returns a copy of the breadcrumbs of the field, which is
+// Breadcrumb, *Breadcrumb, []Breadcrumb or []*Breadcrumb.
+func (d *dataField) getBreadcrumbs() ([]Breadcrumb, bool) {
+	var crumbs []Breadcrumb
+	switch v := d.data[fieldBreadcrumbs].(type) {
+	case Breadcrumb:
+		crumbs = []Breadcrumb{v}
+	case *Breadcrumb:
+		if v != nil {
+			crumbs = []Breadcrumb{*v}
+		}
+	case []Breadcrumb:
+		crumbs = append(crumbs, v...)
+	case []*Breadcrumb:
+		for _, b := range v {
+			if b != nil {
+				crumbs = append(crumbs, *b)
+			}
+		}
+	default:
+		return nil, false
+	}
+	d.omitList[fieldBreadcrumbs] = struct{}{}
+	return crumbs, true
+}
+
 func (d *dataField) getError() (error, bool) {
 	if err, ok := d.data[logrus.ErrorKey].(error); ok {
 		d.omitList[logrus.ErrorKey] = struct{}{}
@@ -90,6 +152,30 @@ func (d *dataField) getHTTPRequest() (*raven.Http, bool) {
 	return nil, false
 }
 
+func (d *dataField) getRawHTTPRequest() (*http.Request, bool) {
+	req, ok := d.data[fieldHTTPRequest].(*http.Request)
+	return req, ok
+}
+
+func (d *dataField) getHTTPRequestBody() ([]byte, bool) {
+	var body []byte
+	switch v := d.data[fieldHTTPBody].(type) {
+	case []byte:
+		body = v
+	case string:
+		body = []byte(v)
+	case *bytes.Buffer:
+		if v == nil {
+			return nil, false
+		}
+		body = v.Bytes()
+	default:
+		return nil, false
+	}
+	d.omitList[fieldHTTPBody] = struct{}{}
+	return body, true
+}
+
 func (d *dataField) getEventID() (string, bool) {
 	eventID, ok := d.data[fieldEventID].(string)
 	if !ok {
@@ -106,32 +192,87 @@ func (d *dataField) getEventID() (string, bool) {
 	return uuid.noDashString(), true
 }
 
-func (d *dataField) getUser() (*raven.User, bool) {
+// isUserField reports whether key is one of the fields of raven.User and
+// the segment.
+func isUserField(key string) bool {
+	switch key {
+	case fieldUserName, fieldUserEmail, fieldUserID, fieldUserIP, fieldUserSegment:
+		return true
+	}
+	return false
+}
+
+// getUser returns the user of the fields. When attributes is true, the other
+// fields prefixed with "user_" are sent as additional attributes of the user,
+// whose names are the field names without "user_".
+func (d *dataField) getUser(attributes bool) (*User, bool) {
 	data := d.data
 	if v, ok := data[fieldUser]; ok {
 		switch val := v.(type) {
 		case *raven.User:
+			if val == nil {
+				break
+			}
 			d.omitList[fieldUser] = struct{}{}
-			return val, true
+			return &User{User: *val}, true
 		case raven.User:
 			d.omitList[fieldUser] = struct{}{}
-			return &val, true
+			return &User{User: val}, true
+		case SentryUser:
+			if user, ok := newUserFromSentryUser(val); ok {
+				d.omitList[fieldUser] = struct{}{}
+				return user, true
+			}
 		}
 	}
 
-	username, _ := data["user_name"].(string)
-	email, _ := data["user_email"].(string)
-	id, _ := data["user_id"].(string)
-	ip, _ := data["user_ip"].(string)
+	username, _ := data[fieldUserName].(string)
+	email, _ := data[fieldUserEmail].(string)
+	id, _ := formatUserID(data[fieldUserID])
+	ip, _ := data[fieldUserIP].(string)
+	segment, _ := data[fieldUserSegment].(string)
 
-	if username == "" && email == "" && id == "" && ip == "" {
+	var extra map[string]interface{}
+	var consumed []string
+	for k, v := range data {
+		if !attributes || !strings.HasPrefix(k, fieldUserPrefix) || isUserField(k) {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]interface{})
+		}
+		extra[strings.TrimPrefix(k, fieldUserPrefix)] = formatData(v)
+		consumed = append(consumed, k)
+	}
+
+	if username == "" && email == "" && id == "" && ip == "" && segment == "" && len(extra) == 0 {
 		return nil, false
 	}
 
-	return &raven.User{
-		ID:       id,
-		Username: username,
-		Email:    email,
-		IP:       ip,
+	// the consumed fields are sent only as the user, not as extra data
+	for _, k := range consumed {
+		d.omitList[k] = struct{}{}
+	}
+	for k, v := range map[string]string{
+		fieldUserName:    username,
+		fieldUserEmail:   email,
+		fieldUserID:      id,
+		fieldUserIP:      ip,
+		fieldUserSegment: segment,
+	} {
+		if v != "" {
+			d.omitList[k] = struct{}{}
+		}
+	}
+
+	return &User{
+		User: raven.User{
+			ID:       id,
+			Username: username,
+			Email:    email,
+			IP:       ip,
+		},
+		Segment: segment,
+		Data:    extra,
 	}, true
 }
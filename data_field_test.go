@@ -1,6 +1,7 @@
 package logrus_sentry
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"net/http"
@@ -296,6 +297,41 @@ func TestGetHTTPRequest(t *testing.T) {
 	}
 }
 
+func TestGetHTTPRequestBodyFromField(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		key         string
+		value       interface{}
+		expected    bool
+		description string
+	}{
+		{"http_request_body", []byte("body"), true, "valid bytes body"},
+		{"http_request_body", "body", true, "valid string body"},
+		{"http_request_body", bytes.NewBufferString("body"), true, "valid buffer body"},
+		{"not_http_request_body", "body", false, "invalid key"},
+		{"http_request_body", 1, false, "invalid value type"},
+		{"http_request_body", (*bytes.Buffer)(nil), false, "invalid nil buffer"},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+
+		fields := logrus.Fields{}
+		fields[tt.key] = tt.value
+
+		df := newDataField(fields)
+		body, ok := df.getHTTPRequestBody()
+		a.Equal(tt.expected, ok, target)
+		if ok {
+			a.Equal("body", string(body), target)
+			a.True(df.isOmit("http_request_body"), "`http_request_body` should be in omitList")
+		} else {
+			a.False(df.isOmit("http_request_body"), "`http_request_body` should not be in omitList")
+		}
+	}
+}
+
 func TestGetEventID(t *testing.T) {
 	a := assert.New(t)
 
@@ -351,6 +387,10 @@ func TestGetUser(t *testing.T) {
 		{"user", 1, false, "invalid value type"},
 		{"user", true, false, "invalid value type"},
 		{"user", struct{}{}, false, "invalid value type"},
+		{"user", mySentryUser{}, true, "valid SentryUser"},
+		{"user", &mySentryUser{}, true, "valid SentryUser"},
+		{"user", (*mySentryUser)(nil), false, "nil SentryUser"},
+		{"user", (*raven.User)(nil), false, "nil user"},
 	}
 
 	for _, tt := range tests {
@@ -360,10 +400,10 @@ func TestGetUser(t *testing.T) {
 		fields[tt.key] = tt.value
 
 		df := newDataField(fields)
-		user, ok := df.getUser()
+		user, ok := df.getUser(false)
 		a.Equal(tt.expected, ok, target)
 		if ok {
-			a.IsType(&raven.User{}, user, target)
+			a.IsType(&User{}, user, target)
 			a.True(df.isOmit("user"), "`user` should be in omitList")
 		} else {
 			a.False(df.isOmit("user"), "`user` should not be in omitList")
@@ -399,6 +439,12 @@ func TestGetUserFromString(t *testing.T) {
 			"user_id":    errors.New("user_id"),
 			"user_ip":    "",
 		}, false, "invalid types"},
+		{map[string]interface{}{"user_id": 1001}, true, "valid numeric user_id"},
+		{map[string]interface{}{"user_id": uint64(1001)}, true, "valid numeric user_id"},
+		{map[string]interface{}{"user_segment": "paid"}, true, "valid user_segment"},
+		{map[string]interface{}{"user_plan": "enterprise"}, false, "additional attribute not added"},
+		{map[string]interface{}{"user_agent": "curl/7.0"}, false, "additional attribute not added"},
+		{map[string]interface{}{"username": "name"}, false, "invalid prefix"},
 	}
 
 	for _, tt := range tests {
@@ -407,10 +453,61 @@ func TestGetUserFromString(t *testing.T) {
 		fields := logrus.Fields(tt.data)
 
 		df := newDataField(fields)
-		user, ok := df.getUser()
+		user, ok := df.getUser(false)
 		a.Equal(tt.expected, ok, target)
 		if ok {
-			a.IsType(&raven.User{}, user, target)
+			a.IsType(&User{}, user, target)
 		}
 	}
 }
+
+type mySentryUser struct{}
+
+func (mySentryUser) GetSentryUser() (id, username, email, ip, segment string, data map[string]interface{}) {
+	return "1001", "name", "example@example.com", "0.0.0.0", "paid", map[string]interface{}{"plan": "enterprise"}
+}
+
+func TestGetUserAttributes(t *testing.T) {
+	a := assert.New(t)
+
+	df := newDataField(logrus.Fields{"user": mySentryUser{}})
+	user, ok := df.getUser(false)
+	a.True(ok)
+	a.Equal("1001", user.ID)
+	a.Equal("name", user.Username)
+	a.Equal("example@example.com", user.Email)
+	a.Equal("0.0.0.0", user.IP)
+	a.Equal("paid", user.Segment)
+	a.Equal(map[string]interface{}{"plan": "enterprise"}, user.Data)
+
+	df = newDataField(logrus.Fields{
+		"user_id":      int64(1001),
+		"user_segment": "paid",
+		"user_plan":    "enterprise",
+		"user_since":   errors.New("2018"),
+		"user_agent":   "curl/7.0",
+	})
+	user, ok = df.getUser(true)
+	a.True(ok)
+	a.Equal("1001", user.ID)
+	a.Equal("paid", user.Segment)
+	a.Equal(map[string]interface{}{"plan": "enterprise", "since": "2018", "agent": "curl/7.0"}, user.Data)
+
+	// the consumed fields are not sent as extra data
+	for _, k := range []string{"user_id", "user_segment", "user_plan", "user_since", "user_agent"} {
+		a.True(df.isOmit(k), k)
+	}
+
+	// without the prefix, the other fields are sent as extra data
+	df = newDataField(logrus.Fields{"user_id": "1001", "user_plan": "enterprise"})
+	user, ok = df.getUser(false)
+	a.True(ok)
+	a.Nil(user.Data)
+	a.False(df.isOmit("user_plan"), "user_plan should be sent as extra data")
+
+	// additional attributes alone are the user
+	df = newDataField(logrus.Fields{"user_plan": "enterprise"})
+	user, ok = df.getUser(true)
+	a.True(ok)
+	a.Equal(map[string]interface{}{"plan": "enterprise"}, user.Data)
+}
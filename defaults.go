@@ -0,0 +1,41 @@
+package logrus_sentry
+
+// SetDefaultTag sets a default tag of events, independent of the tags of the
+// client. The tags of scopes and entries take precedence over it.
+// It's safe to call while logging.
+func (hook *SentryHook) SetDefaultTag(key, value string) {
+	hook.defaults.SetTag(key, value)
+}
+
+// SetDefaultExtra sets a default value of the extra data of events. The extra
+// data of scopes and the fields of entries take precedence over it.
+// It's safe to call while logging.
+func (hook *SentryHook) SetDefaultExtra(key string, value interface{}) {
+	hook.defaults.SetExtra(key, value)
+}
+
+// SetDefaultContext sets a default context of events, e.g. "app" or
+// "runtime". The contexts of scopes take precedence over it.
+// It's safe to call while logging.
+func (hook *SentryHook) SetDefaultContext(name string, value interface{}) {
+	hook.defaults.SetContext(name, value)
+}
+
+// processDefaults merges the defaults of the hook into the event, keeping
+// what the entry and the scope have set. The extra data of the entry is set
+// later, and overrides the defaults.
+func processDefaults(ev *Event) *Event {
+	hook, packet := ev.hook, ev.Packet
+	if hook.defaults != nil {
+		hook.defaults.apply(ev)
+	}
+
+	if len(hook.fingerprintPrefix) != 0 && !ev.transaction {
+		fingerprint := packet.Fingerprint
+		if len(fingerprint) == 0 {
+			fingerprint = []string{"{{ default }}"}
+		}
+		packet.Fingerprint = append(append([]string(nil), hook.fingerprintPrefix...), fingerprint...)
+	}
+	return ev
+}
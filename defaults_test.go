@@ -0,0 +1,90 @@
+package logrus_sentry
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaults(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.SetDefaultTag("region", "eu")
+		hook.SetDefaultTag("site", "default")
+		hook.SetDefaultExtra("build", "abc")
+		hook.SetDefaultExtra("order_id", "default")
+		hook.SetDefaultContext("app", map[string]interface{}{"version": "1.0.0"})
+		hook.SetDefaultContext("runtime", map[string]interface{}{"name": "go"})
+		logger.Hooks.Add(hook)
+
+		logger.Error("failed")
+		packet := <-pch
+		assert.Equal(t, raven.Tags{
+			{Key: "region", Value: "eu"},
+			{Key: "site", Value: "default"},
+		}, packet.Tags)
+		assert.Equal(t, "abc", packet.Extra["build"])
+		assert.Equal(t, map[string]interface{}{"version": "1.0.0"}, packet.Contexts["app"])
+
+		// hook defaults < scope < entry fields
+		scope := NewScope()
+		scope.SetTag("site", "scope")
+		scope.SetTag("region", "scope")
+		scope.SetExtra("order_id", "scope")
+		scope.SetContext("app", map[string]interface{}{"version": "2.0.0"})
+		ctx := WithScope(context.Background(), scope)
+		logger.WithContext(ctx).WithFields(logrus.Fields{
+			"tags":     map[string]string{"site": "entry"},
+			"order_id": "entry",
+		}).Error("failed")
+		packet = <-pch
+		assert.Equal(t, raven.Tags{
+			{Key: "site", Value: "entry"},
+			{Key: "region", Value: "scope"},
+		}, packet.Tags)
+		assert.Equal(t, "entry", packet.Extra["order_id"])
+		assert.Equal(t, "abc", packet.Extra["build"])
+		assert.Equal(t, map[string]interface{}{"version": "2.0.0"}, packet.Contexts["app"])
+		assert.Equal(t, map[string]interface{}{"name": "go"}, packet.Contexts["runtime"])
+
+		// the defaults of derived hooks are independent
+		derived := hook.With()
+		derived.SetDefaultTag("region", "us")
+		assert.Equal(t, "eu", hook.defaults.tags["region"])
+	})
+}
+
+func TestDefaultsConcurrency(t *testing.T) {
+	hook, err := NewSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	logger := getTestLogger()
+	logger.Hooks.Add(hook)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			hook.SetDefaultTag("region", "eu")
+			hook.SetDefaultExtra("build", "abc")
+			hook.SetDefaultContext("app", map[string]interface{}{"version": "1.0.0"})
+		}()
+		go func() {
+			defer wg.Done()
+			logger.Error("failed")
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,131 @@
+package logrus_sentry
+
+import (
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HookOption overrides a default of the hooks derived by With.
+type HookOption func(hook *SentryHook)
+
+// WithTags adds the default tags of events. The tags of entries and scopes
+// take precedence over them.
+func WithTags(tags map[string]string) HookOption {
+	return func(hook *SentryHook) {
+		for k, v := range tags {
+			hook.defaults.SetTag(k, v)
+		}
+	}
+}
+
+// WithLoggerName sets the default logger name of events and breadcrumbs.
+// The "logger" field of entries takes precedence over it.
+func WithLoggerName(name string) HookOption {
+	return func(hook *SentryHook) {
+		hook.loggerName = name
+	}
+}
+
+// WithFingerprintPrefix sets the prefix of the fingerprint of events, so the
+// events of a component are grouped apart from the others. Events without a
+// fingerprint are grouped by the prefix and the default grouping.
+func WithFingerprintPrefix(prefix ...string) HookOption {
+	return func(hook *SentryHook) {
+		hook.fingerprintPrefix = append([]string(nil), prefix...)
+	}
+}
+
+// WithLevels sets the levels of events.
+func WithLevels(levels ...logrus.Level) HookOption {
+	return func(hook *SentryHook) {
+		hook.levels = append([]logrus.Level(nil), levels...)
+	}
+}
+
+// With returns a hook derived from the hook, whose defaults are overridden by
+// the options. The derived hook shares the client, the queue of asynchronous
+// events, the breadcrumbs and the stats of the hook, so flushing either of
+// them waits for the events of both. The other settings are copied, and can be
+// changed independently.
+func (hook *SentryHook) With(options ...HookOption) *SentryHook {
+	ignoreFields := make(map[string]struct{}, len(hook.ignoreFields))
+	for k := range hook.ignoreFields {
+		ignoreFields[k] = struct{}{}
+	}
+	extraFilters := make(map[string]func(interface{}) interface{}, len(hook.extraFilters))
+	for k, fn := range hook.extraFilters {
+		extraFilters[k] = fn
+	}
+	typeFilters := make(map[reflect.Type]func(interface{}) interface{}, len(hook.typeFilters))
+	for k, fn := range hook.typeFilters {
+		typeFilters[k] = fn
+	}
+
+	defaults := NewScope()
+	if hook.defaults != nil {
+		defaults = hook.defaults.cloneData()
+	}
+
+	secretConf := hook.SecretConfiguration
+	// copied so patterns can be disabled per hook
+	secretConf.Patterns = append([]SecretPattern(nil), secretConf.Patterns...)
+
+	// the slices are capped so appending to them doesn't modify the hook
+	derived := &SentryHook{
+		Timeout:                  hook.Timeout,
+		StacktraceConfiguration:  hook.StacktraceConfiguration,
+		HTTPRequestConfiguration: hook.HTTPRequestConfiguration,
+		NormalizeConfiguration:   hook.NormalizeConfiguration,
+		ScrubConfiguration:       hook.ScrubConfiguration,
+		URLConfiguration:         hook.URLConfiguration,
+		SecretConfiguration:      secretConf,
+		BreadcrumbConfiguration:  hook.BreadcrumbConfiguration,
+		SQLConfiguration:         hook.SQLConfiguration,
+		TraceConfiguration:       hook.TraceConfiguration,
+		TracingConfiguration:     hook.TracingConfiguration,
+		PrivacyConfiguration:     hook.PrivacyConfiguration,
+
+		client: hook.client,
+		levels: hook.levels[:len(hook.levels):len(hook.levels)],
+
+		serverName:   hook.serverName,
+		ignoreFields: ignoreFields,
+		fields: fieldSelector{
+			ignore:        hook.fields.ignore[:len(hook.fields.ignore):len(hook.fields.ignore)],
+			allow:         hook.fields.allow[:len(hook.fields.allow):len(hook.fields.allow)],
+			allowlistOnly: hook.fields.allowlistOnly,
+		},
+		extraFilters:  extraFilters,
+		typeFilters:   typeFilters,
+		ifaceFilters:  hook.ifaceFilters[:len(hook.ifaceFilters):len(hook.ifaceFilters)],
+		errorHandlers: hook.errorHandlers[:len(hook.errorHandlers):len(hook.errorHandlers)],
+		beforeSend:    hook.beforeSend[:len(hook.beforeSend):len(hook.beforeSend)],
+		stages:        hook.stages[:len(hook.stages):len(hook.stages)],
+		stats:         hook.stats,
+
+		contextExtractors: hook.contextExtractors[:len(hook.contextExtractors):len(hook.contextExtractors)],
+
+		defaults:            defaults,
+		userAttributePrefix: hook.userAttributePrefix,
+
+		parent:            hook.root(),
+		loggerName:        hook.loggerName,
+		fingerprintPrefix: hook.fingerprintPrefix,
+
+		asynchronous: hook.asynchronous,
+	}
+	for _, opt := range options {
+		opt(derived)
+	}
+	return derived
+}
+
+// root returns the hook which owns the queue and the breadcrumbs shared by
+// the derived hooks.
+func (hook *SentryHook) root() *SentryHook {
+	if hook.parent != nil {
+		return hook.parent
+	}
+	return hook
+}
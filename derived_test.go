@@ -0,0 +1,100 @@
+package logrus_sentry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWith(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		derived := hook.With(
+			WithTags(map[string]string{"component": "billing", "site": "default"}),
+			WithLoggerName("billing"),
+			WithFingerprintPrefix("billing"),
+			WithLevels(logrus.ErrorLevel, logrus.WarnLevel),
+		)
+		assert.Equal(t, []logrus.Level{logrus.ErrorLevel}, hook.Levels())
+		assert.Equal(t, []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel}, derived.Levels())
+
+		logger := getTestLogger()
+		logger.Hooks.Add(derived)
+
+		logger.Warn("payment retried")
+		packet := <-pch
+		assert.Equal(t, "billing", packet.Logger)
+		assert.Equal(t, []string{"billing", "{{ default }}"}, packet.Fingerprint)
+		assert.Equal(t, raven.Tags{
+			{Key: "component", Value: "billing"},
+			{Key: "site", Value: "default"},
+		}, packet.Tags)
+
+		// the fields of entries and scopes take precedence over the defaults
+		scope := NewScope()
+		scope.SetTag("site", "scope")
+		logger.WithContext(WithScope(context.Background(), scope)).WithFields(logrus.Fields{
+			"logger":      "payments",
+			"fingerprint": []string{"payment"},
+			"tags":        map[string]string{"component": "payments"},
+		}).Error("payment failed")
+		packet = <-pch
+		assert.Equal(t, "payments", packet.Logger)
+		assert.Equal(t, []string{"billing", "payment"}, packet.Fingerprint)
+		assert.Equal(t, raven.Tags{
+			{Key: "component", Value: "payments"},
+			{Key: "site", Value: "scope"},
+		}, packet.Tags)
+
+		// the hook is not changed by the derived hook
+		logger = getTestLogger()
+		logger.Hooks.Add(hook)
+		logger.Error("failed")
+		packet = <-pch
+		assert.Equal(t, "root", packet.Logger)
+		assert.Empty(t, packet.Fingerprint)
+		assert.Empty(t, packet.Tags)
+
+		// options of nested derived hooks are merged
+		nested := derived.With(WithTags(map[string]string{"site": "nested"}))
+		assert.Equal(t, map[string]string{"component": "billing", "site": "nested"}, nested.defaults.tags)
+		assert.Equal(t, "billing", nested.loggerName)
+		assert.True(t, nested.root() == hook)
+	})
+}
+
+func TestWithShared(t *testing.T) {
+	a := assert.New(t)
+
+	hook, err := NewAsyncSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	a.NoError(err)
+	hook.BreadcrumbConfiguration.Enable = true
+
+	derived := hook.With()
+	derived.AddBeforeSend(func(entry *logrus.Entry, packet *raven.Packet) *raven.Packet {
+		return nil
+	})
+	derived.SecretConfiguration.EnablePattern("jwt", false)
+	a.Empty(hook.beforeSend)
+	a.False(hook.SecretConfiguration.Patterns[3].Disable)
+
+	logger := getTestLogger()
+	logger.Hooks.Add(derived)
+	logger.Info("started")
+	logger.Error("dropped")
+	derived.Flush()
+
+	// the stats and breadcrumbs are shared
+	a.Equal(uint64(1), hook.Stats().Dropped)
+	if values := hook.breadcrumbs.snapshot(); a.Len(values, 1) {
+		a.Equal("started", values[0].Message)
+	}
+}
@@ -0,0 +1,69 @@
+package logrus_sentry
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// encodings of []byte values
+const (
+	BytesEncodingBase64 = "base64"
+	BytesEncodingHex    = "hex"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// encode converts a value which can't be encoded into JSON properly, such
+// as channels, funcs, complex numbers and NaN, into a string.
+// It returns false when the value should be walked by the normalizer.
+func (n *normalizer) encode(v reflect.Value) (interface{}, bool) {
+	if v.Type() == timeType && v.CanInterface() {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), true
+	}
+
+	if v.CanInterface() {
+		switch formatted := formatData(v.Interface()).(type) {
+		case json.Marshaler:
+			// make sure the value can be marshaled, otherwise the whole event is lost
+			if _, err := formatted.MarshalJSON(); err != nil {
+				return fmt.Sprintf("[unserializable: %s]", err), true
+			}
+			return formatted, true
+		case string:
+			return n.truncateString(formatted), true
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return n.truncateString(v.String()), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return n.encodeBytes(v.Bytes()), true
+		}
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprint(f), true
+		}
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v.Complex()), true
+	case reflect.Func, reflect.Chan:
+		return v.Type().String(), true
+	case reflect.UnsafePointer:
+		return fmt.Sprintf("%#x", v.Pointer()), true
+	}
+	return nil, false
+}
+
+func (n *normalizer) encodeBytes(b []byte) string {
+	if n.conf.BytesEncoding == BytesEncodingHex {
+		return n.truncateString(hex.EncodeToString(b))
+	}
+	return n.truncateString(base64.StdEncoding.EncodeToString(b))
+}
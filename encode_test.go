@@ -0,0 +1,110 @@
+package logrus_sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type myMarshaler struct{}
+
+func (myMarshaler) MarshalJSON() ([]byte, error) { return []byte(`"myMarshaler!"`), nil }
+
+type brokenMarshaler struct{}
+
+func (brokenMarshaler) MarshalJSON() ([]byte, error) { return nil, errors.New("broken") }
+
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("panicStringer!") }
+
+func TestNormalizeEncode(t *testing.T) {
+	a := assert.New(t)
+
+	conf := &NormalizeConfiguration{Enable: true}
+	hexConf := &NormalizeConfiguration{Enable: true, BytesEncoding: BytesEncodingHex}
+
+	ch := make(chan int)
+	var nilCh chan string
+	x := 1
+
+	tests := []struct {
+		conf        *NormalizeConfiguration
+		value       interface{}
+		expected    interface{}
+		description string
+	}{
+		{conf, time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC), "2018-01-02T03:04:05Z", "time"},
+		{conf, 90 * time.Second, "1m30s", "duration"},
+		{conf, []byte("bytes"), "Ynl0ZXM=", "base64 bytes"},
+		{hexConf, []byte("bytes"), "6279746573", "hex bytes"},
+		{conf, complex(1, 2), "(1+2i)", "complex"},
+		{conf, math.NaN(), "NaN", "NaN"},
+		{conf, math.Inf(-1), "-Inf", "negative infinity"},
+		{conf, 1.5, 1.5, "float"},
+		{conf, ch, "chan int", "channel"},
+		{conf, nilCh, "chan string", "nil channel"},
+		{conf, TestNormalizeEncode, "func(*testing.T)", "func"},
+		{conf, unsafe.Pointer(&x), fmt.Sprintf("%#x", uintptr(unsafe.Pointer(&x))), "unsafe pointer"},
+		{conf, myMarshaler{}, myMarshaler{}, "json marshaler"},
+		{conf, brokenMarshaler{}, "[unserializable: broken]", "broken json marshaler"},
+		{conf, panicStringer{}, "[unserializable: panicStringer!]", "panic in String()"},
+		{conf, map[string]interface{}{
+			"bytes": []byte{0xff},
+			"func":  func() {},
+			"time":  time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+		}, map[string]interface{}{
+			"bytes": "/w==",
+			"func":  "func()",
+			"time":  "2018-01-02T03:04:05Z",
+		}, "nested values"},
+		{conf, map[[2]int]bool{{1, 2}: true}, map[string]interface{}{"[1 2]": true}, "map with array key"},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt.description)
+
+		result := tt.conf.normalize(tt.value)
+		a.Equal(tt.expected, result, target)
+
+		_, err := json.Marshal(result)
+		a.NoError(err, target)
+	}
+}
+
+func TestFormatExtraDataEncodeWithoutNormalization(t *testing.T) {
+	a := assert.New(t)
+
+	hook := SentryHook{
+		ignoreFields: make(map[string]struct{}),
+		extraFilters: make(map[string]func(interface{}) interface{}),
+		NormalizeConfiguration: NormalizeConfiguration{
+			Enable:          false,
+			MaxStringLength: 3,
+			BytesEncoding:   BytesEncodingHex,
+		},
+	}
+
+	df := newDataField(map[string]interface{}{
+		"long":    "foobar",
+		"nan":     math.NaN(),
+		"bytes":   []byte{0xca, 0xfe},
+		"channel": make(chan int),
+		"nested":  map[int]interface{}{1: complex(1, 2)},
+	})
+	result := hook.formatExtraData(df)
+	a.Equal("foobar", result["long"], "limits should not be applied")
+	a.Equal("NaN", result["nan"])
+	a.Equal([]byte{0xca, 0xfe}, result["bytes"], "encodable values should be kept")
+	a.Equal("chan int", result["channel"])
+	a.Equal(map[string]interface{}{"1": "(1+2i)"}, result["nested"])
+
+	_, err := json.Marshal(result)
+	a.NoError(err)
+}
@@ -0,0 +1,116 @@
+package logrus_sentry
+
+import (
+	"path"
+	"strings"
+)
+
+// fieldPattern is a pattern of a field path split by ".".
+// Each segment is matched by path.Match, so "*" matches any key.
+type fieldPattern []string
+
+func newFieldPattern(pattern string) fieldPattern {
+	return fieldPattern(strings.Split(pattern, "."))
+}
+
+func (p fieldPattern) matchHead(key string) bool {
+	ok, err := path.Match(p[0], key)
+	return ok && err == nil
+}
+
+// fieldSelector selects the fields sent as extra data by ignore and allow
+// patterns.
+type fieldSelector struct {
+	ignore []fieldPattern
+	allow  []fieldPattern
+	// when it's true, only the fields matched by allow patterns are sent
+	allowlistOnly bool
+}
+
+// fieldRules are the patterns applied to the nested values of a field.
+type fieldRules struct {
+	ignore []fieldPattern
+	allow  []fieldPattern
+	// whether only the values matched by allow are sent
+	restricted bool
+}
+
+func (r fieldRules) isEmpty() bool {
+	return len(r.ignore) == 0 && !r.restricted
+}
+
+func (s *fieldSelector) rules() fieldRules {
+	return fieldRules{
+		ignore:     s.ignore,
+		allow:      s.allow,
+		restricted: s.allowlistOnly,
+	}
+}
+
+// match reports whether the value of key is sent, and returns the rules
+// applied to its nested values.
+func (r fieldRules) match(key string) (bool, fieldRules) {
+	var child fieldRules
+	for _, p := range r.ignore {
+		if !p.matchHead(key) {
+			continue
+		}
+		if len(p) == 1 {
+			return false, child
+		}
+		child.ignore = append(child.ignore, p[1:])
+	}
+
+	if !r.restricted {
+		return true, child
+	}
+	allowed := false
+	for _, p := range r.allow {
+		if !p.matchHead(key) {
+			continue
+		}
+		if len(p) == 1 {
+			// the whole value is allowed
+			return true, fieldRules{ignore: child.ignore}
+		}
+		allowed = true
+		child.allow = append(child.allow, p[1:])
+	}
+	child.restricted = true
+	return allowed, child
+}
+
+// filter applies the rules to the nested values of maps and slices, which
+// are created by the normalizer. Slices are transparent to the patterns.
+// It returns false when the value is not sent.
+func (r fieldRules) filter(value interface{}) (interface{}, bool) {
+	if r.isEmpty() {
+		return value, true
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			ok, child := r.match(k)
+			if !ok {
+				continue
+			}
+			if item, ok = child.filter(item); ok {
+				result[k] = item
+			}
+		}
+		return result, true
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if item, ok := r.filter(item); ok {
+				result = append(result, item)
+			}
+		}
+		return result, true
+	}
+
+	// a value which has no nested values can't match the rest of allow patterns
+	return value, !r.restricted
+}
@@ -0,0 +1,160 @@
+package logrus_sentry
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type selectorCard struct {
+	Number string `json:"number"`
+	Brand  string `json:"brand"`
+}
+
+type selectorPayload struct {
+	Card  selectorCard `json:"card"`
+	Items []selectorCard
+}
+
+func newSelectorHook(allowlist bool, ignore, allow []string) *SentryHook {
+	hook := &SentryHook{
+		ignoreFields: make(map[string]struct{}),
+		extraFilters: make(map[string]func(interface{}) interface{}),
+	}
+	for _, p := range ignore {
+		hook.AddIgnorePattern(p)
+	}
+	for _, p := range allow {
+		hook.AddAllowPattern(p)
+	}
+	hook.SetAllowlistMode(allowlist)
+	return hook
+}
+
+func TestFieldSelector(t *testing.T) {
+	a := assert.New(t)
+
+	payload := selectorPayload{
+		Card:  selectorCard{Number: "4111111111111111", Brand: "visa"},
+		Items: []selectorCard{{Number: "1", Brand: "a"}, {Number: "2", Brand: "b"}},
+	}
+	fields := logrus.Fields{
+		"auth_token": "secret",
+		"auth_user":  "alice",
+		"order":      13,
+		"payload":    payload,
+		"nested": map[string]interface{}{
+			"card": map[string]interface{}{"number": "4111", "cvc": "123"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		allowlist bool
+		ignore    []string
+		allow     []string
+		expected  map[string]interface{}
+	}{
+		{
+			name:   "glob",
+			ignore: []string{"auth_*"},
+			expected: map[string]interface{}{
+				"order":   13,
+				"payload": payload,
+				"nested":  fields["nested"],
+			},
+		},
+		{
+			name:   "nested path into structs and maps",
+			ignore: []string{"auth_*", "*.card.number", "payload.Items.brand"},
+			expected: map[string]interface{}{
+				"order": 13,
+				"payload": map[string]interface{}{
+					"card": map[string]interface{}{"brand": "visa"},
+					"Items": []interface{}{
+						map[string]interface{}{"number": "1"},
+						map[string]interface{}{"number": "2"},
+					},
+				},
+				"nested": map[string]interface{}{
+					"card": map[string]interface{}{"cvc": "123"},
+				},
+			},
+		},
+		{
+			name:      "allowlist",
+			allowlist: true,
+			allow:     []string{"order", "auth_*", "payload.card.brand", "nested.card"},
+			ignore:    []string{"auth_token", "nested.card.cvc"},
+			expected: map[string]interface{}{
+				"auth_user": "alice",
+				"order":     13,
+				"payload": map[string]interface{}{
+					"card": map[string]interface{}{"brand": "visa"},
+				},
+				"nested": map[string]interface{}{
+					"card": map[string]interface{}{"number": "4111"},
+				},
+			},
+		},
+		{
+			name:      "allowlist without allow patterns",
+			allowlist: true,
+			expected:  map[string]interface{}{},
+		},
+		{
+			name:      "allowlist of a path deeper than the value",
+			allowlist: true,
+			allow:     []string{"order.value"},
+			expected:  map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+
+		for _, normalize := range []bool{true, false} {
+			hook := newSelectorHook(tt.allowlist, tt.ignore, tt.allow)
+			hook.NormalizeConfiguration.Enable = normalize
+			result := hook.formatExtraData(newDataField(fields))
+
+			if normalize {
+				// compare the normalized values
+				a.Equal(hook.NormalizeConfiguration.normalize(tt.expected), hook.NormalizeConfiguration.normalize(result), target)
+				continue
+			}
+			a.Equal(len(tt.expected), len(result), target)
+			for k := range tt.expected {
+				a.Contains(result, k, target)
+			}
+		}
+	}
+}
+
+func TestFieldRulesMatch(t *testing.T) {
+	a := assert.New(t)
+
+	rules := fieldRules{
+		ignore:     []fieldPattern{newFieldPattern("a.b"), newFieldPattern("x?")},
+		allow:      []fieldPattern{newFieldPattern("a.c"), newFieldPattern("[xy]*")},
+		restricted: true,
+	}
+
+	ok, child := rules.match("a")
+	a.True(ok)
+	a.Equal([]fieldPattern{{"b"}}, child.ignore)
+	a.Equal([]fieldPattern{{"c"}}, child.allow)
+	a.True(child.restricted)
+
+	ok, _ = rules.match("x1")
+	a.False(ok)
+
+	ok, child = rules.match("yes")
+	a.True(ok)
+	a.True(child.isEmpty())
+
+	ok, _ = rules.match("zzz")
+	a.False(ok)
+}
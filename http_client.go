@@ -0,0 +1,73 @@
+package logrus_sentry
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/getsentry/raven-go"
+)
+
+// BreadcrumbTransport is an http.RoundTripper which records outgoing requests
+// as "http" breadcrumbs into the breadcrumb buffer of the request context.
+// It's created by NewBreadcrumbTransport, and the zero value only sends
+// requests.
+type BreadcrumbTransport struct {
+	// the transport which sends requests. http.DefaultTransport is used when
+	// it's nil
+	Base http.RoundTripper
+
+	hook *SentryHook
+}
+
+// NewBreadcrumbTransport returns a BreadcrumbTransport recording breadcrumbs
+// into the hook. Breadcrumbs must be enabled on the hook.
+func (hook *SentryHook) NewBreadcrumbTransport(base http.RoundTripper) *BreadcrumbTransport {
+	return &BreadcrumbTransport{
+		Base: base,
+		hook: hook,
+	}
+}
+
+// RoundTrip sends the request by Base, and records it.
+func (t *BreadcrumbTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if t.hook == nil {
+		return base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	duration := time.Since(start)
+
+	data := map[string]interface{}{
+		"method":   req.Method,
+		"url":      t.hook.URLConfiguration.redactURL(req.URL.String()),
+		"duration": duration.String(),
+	}
+	level := raven.INFO
+	switch {
+	case err != nil:
+		level = raven.ERROR
+		data["error"] = err.Error()
+	case resp.StatusCode >= 500:
+		level = raven.ERROR
+	case resp.StatusCode >= 400:
+		level = raven.WARNING
+	}
+	if resp != nil {
+		data["status_code"] = resp.StatusCode
+	}
+
+	t.hook.addBreadcrumb(req.Context(), Breadcrumb{
+		Timestamp: start,
+		Type:      "http",
+		Category:  "http",
+		Level:     level,
+		Data:      data,
+	})
+	return resp, err
+}
@@ -0,0 +1,244 @@
+package logrus_sentry
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getsentry/raven-go"
+)
+
+// HTTPRequestConfiguration allows for configuring the http_request interface
+type HTTPRequestConfiguration struct {
+	// whether the request body should be sent
+	IncludeBody bool
+	// the maximum number of body bytes to send; larger bodies are truncated
+	// and sent as a plain string
+	MaxBodySize int
+	// the content types of the bodies which will be sent.
+	// "type/*" matches every subtype of the type.
+	ContentTypes []string
+
+	// the headers whose values are masked, matched case-insensitively
+	SensitiveHeaders []string
+	// the cookies whose values are masked. "*" matches every cookie.
+	SensitiveCookies []string
+	// the headers which will be sent. when it's not empty, other headers
+	// are removed, and cookies are removed unless "Cookie" is allowed.
+	// sensitive headers and cookies are still masked.
+	AllowedHeaders []string
+	// whether sensitive headers and cookies are removed instead of masked
+	RemoveSensitive bool
+}
+
+var defaultBodyContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"text/plain",
+}
+
+var defaultSensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Xsrf-Token",
+}
+
+var defaultSensitiveCookies = []string{"*"}
+
+// getHTTPRequest returns the http_request interface, with the request body
+// attached when body capture is enabled.
+func (hook *SentryHook) getHTTPRequest(df *dataField) (*raven.Http, bool) {
+	req, ok := df.getHTTPRequest()
+	if !ok {
+		return nil, false
+	}
+
+	// the body field is consumed even when it is not sent,
+	// so it never ends up in the extra data
+	body, hasBody := df.getHTTPRequestBody()
+
+	conf := &hook.HTTPRequestConfiguration
+	// the content type is read before the header may be removed
+	contentType := req.Headers["Content-Type"]
+	req = conf.sanitize(req)
+	if !conf.IncludeBody || req.Data != nil {
+		return req, true
+	}
+	if !conf.isAllowedContentType(contentType) {
+		return req, true
+	}
+
+	if !hasBody {
+		rawReq, ok := df.getRawHTTPRequest()
+		if !ok {
+			return req, true
+		}
+		body = readRequestBody(rawReq, conf.MaxBodySize)
+	}
+	if len(body) == 0 {
+		return req, true
+	}
+
+	// copy the interface so a *raven.Http given by the caller is not modified
+	withBody := *req
+	withBody.Data = conf.formatBody(contentType, body)
+	return &withBody, true
+}
+
+// sanitize returns a copy of h without sensitive headers and cookies.
+func (conf *HTTPRequestConfiguration) sanitize(h *raven.Http) *raven.Http {
+	if len(conf.SensitiveHeaders) == 0 && len(conf.SensitiveCookies) == 0 && len(conf.AllowedHeaders) == 0 {
+		return h
+	}
+
+	req := *h
+	if len(conf.AllowedHeaders) != 0 && !containsFold(conf.AllowedHeaders, "Cookie") {
+		// the cookies are the Cookie header
+		req.Cookies = ""
+	} else {
+		req.Cookies = conf.sanitizeCookies(req.Cookies)
+	}
+	if req.Headers == nil {
+		return &req
+	}
+
+	req.Headers = make(map[string]string, len(h.Headers))
+	for k, v := range h.Headers {
+		switch {
+		case len(conf.AllowedHeaders) != 0 && !containsFold(conf.AllowedHeaders, k):
+			continue
+		case containsFold(conf.SensitiveHeaders, k):
+			if conf.RemoveSensitive {
+				continue
+			}
+			v = markerFiltered
+		case strings.EqualFold(k, "Cookie"):
+			v = conf.sanitizeCookies(v)
+		}
+		req.Headers[k] = v
+	}
+	return &req
+}
+
+func (conf *HTTPRequestConfiguration) sanitizeCookies(cookies string) string {
+	if len(conf.SensitiveCookies) == 0 {
+		return cookies
+	}
+	return mapCookies(cookies, func(name, value string) (string, bool) {
+		if !containsFold(conf.SensitiveCookies, name) && !containsFold(conf.SensitiveCookies, "*") {
+			return value, true
+		}
+		if conf.RemoveSensitive {
+			return "", false
+		}
+		return markerFiltered, true
+	})
+}
+
+// mapCookies applies fn to the values of the cookies in a Cookie header.
+// The cookie is removed when fn returns false.
+func mapCookies(cookies string, fn func(name, value string) (string, bool)) string {
+	if cookies == "" {
+		return cookies
+	}
+	pairs := strings.Split(cookies, ";")
+	result := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value := pair, ""
+		if i := strings.Index(pair, "="); i >= 0 {
+			name, value = pair[:i], pair[i+1:]
+		}
+		if value, ok := fn(name, value); ok {
+			result = append(result, name+"="+value)
+		}
+	}
+	return strings.Join(result, "; ")
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (conf *HTTPRequestConfiguration) isAllowedContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range conf.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		switch {
+		case allowed == mediaType:
+			return true
+		case strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, allowed[:len(allowed)-1]):
+			return true
+		}
+	}
+	return false
+}
+
+// formatBody converts the body into the structured data of the http interface.
+// JSON and form bodies are parsed, others are sent as a string.
+func (conf *HTTPRequestConfiguration) formatBody(contentType string, body []byte) interface{} {
+	if conf.MaxBodySize > 0 && len(body) > conf.MaxBodySize {
+		return string(body[:conf.MaxBodySize])
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err == nil {
+			return v
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			form := make(map[string]string, len(values))
+			for k, v := range values {
+				form[k] = strings.Join(v, ",")
+			}
+			return form
+		}
+	}
+	return string(body)
+}
+
+// readRequestBody reads a copy of the body by GetBody. The body itself is
+// never read, as the request may be used by other goroutines, e.g. of
+// asynchronous hooks, so it must be passed by the http_request_body field.
+func readRequestBody(req *http.Request, maxSize int) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	return readLimited(rc, maxSize)
+}
+
+// readLimited reads one byte more than maxSize so truncation can be detected.
+func readLimited(r io.Reader, maxSize int) []byte {
+	if maxSize > 0 {
+		r = io.LimitReader(r, int64(maxSize)+1)
+	}
+	body, _ := ioutil.ReadAll(r)
+	return body
+}
@@ -0,0 +1,229 @@
+package logrus_sentry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHTTPRequestBody(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		contentType string
+		body        string
+		maxSize     int
+		expected    interface{}
+		description string
+	}{
+		{"application/json", `{"id":1}`, 100, map[string]interface{}{"id": float64(1)}, "json body"},
+		{"application/json; charset=utf-8", `[1,2]`, 100, []interface{}{float64(1), float64(2)}, "json body with params"},
+		{"application/vnd.api+json", `{"id":1}`, 100, map[string]interface{}{"id": float64(1)}, "json suffix body"},
+		{"application/json", `{"id":`, 100, `{"id":`, "broken json body"},
+		{"application/x-www-form-urlencoded", "a=1&b=2&b=3", 100, map[string]string{"a": "1", "b": "2,3"}, "form body"},
+		{"text/plain", "plain text", 100, "plain text", "text body"},
+		{"application/json", `{"id":12345}`, 5, `{"id"`, "truncated body"},
+		{"application/octet-stream", "binary", 100, nil, "not allowed content type"},
+		{"", "no content type", 100, nil, "no content type"},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+
+		hook := SentryHook{
+			HTTPRequestConfiguration: HTTPRequestConfiguration{
+				IncludeBody:  true,
+				MaxBodySize:  tt.maxSize,
+				ContentTypes: append([]string{"application/vnd.api+json"}, defaultBodyContentTypes...),
+			},
+		}
+		req, _ := http.NewRequest("POST", "/", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+
+		df := newDataField(logrus.Fields{fieldHTTPRequest: req})
+		h, ok := hook.getHTTPRequest(df)
+		a.True(ok, target)
+		a.Equal(tt.expected, h.Data, target)
+
+		// the body must still be readable after the hook
+		rest, _ := ioutil.ReadAll(req.Body)
+		a.Equal(tt.body, string(rest), target)
+	}
+}
+
+func TestGetHTTPRequestBodyWithoutGetBody(t *testing.T) {
+	a := assert.New(t)
+
+	hook := SentryHook{
+		HTTPRequestConfiguration: HTTPRequestConfiguration{
+			IncludeBody:  true,
+			ContentTypes: defaultBodyContentTypes,
+		},
+	}
+	req, _ := http.NewRequest("POST", "/", strings.NewReader("plain text"))
+	req.GetBody = nil
+	req.Header.Set("Content-Type", "text/plain")
+	body := req.Body
+
+	df := newDataField(logrus.Fields{fieldHTTPRequest: req})
+	h, ok := hook.getHTTPRequest(df)
+	a.True(ok)
+	a.Nil(h.Data, "the body should be passed by the field")
+	a.True(body == req.Body, "the body should not be replaced")
+	rest, _ := ioutil.ReadAll(req.Body)
+	a.Equal("plain text", string(rest))
+
+	df = newDataField(logrus.Fields{fieldHTTPRequest: req, fieldHTTPBody: "buffered"})
+	h, ok = hook.getHTTPRequest(df)
+	a.True(ok)
+	a.Equal("buffered", h.Data)
+}
+
+func TestGetHTTPRequestBodyWithAllowedHeaders(t *testing.T) {
+	a := assert.New(t)
+
+	hook := SentryHook{
+		HTTPRequestConfiguration: HTTPRequestConfiguration{
+			IncludeBody:    true,
+			ContentTypes:   defaultBodyContentTypes,
+			AllowedHeaders: []string{"User-Agent"},
+		},
+	}
+	req, _ := http.NewRequest("POST", "/", strings.NewReader("plain text"))
+	req.Header.Set("Content-Type", "text/plain")
+
+	df := newDataField(logrus.Fields{fieldHTTPRequest: req})
+	h, ok := hook.getHTTPRequest(df)
+	a.True(ok)
+	a.Equal("plain text", h.Data, "the body should be sent without the Content-Type header")
+	a.NotContains(h.Headers, "Content-Type")
+}
+
+func TestGetHTTPRequestBodyField(t *testing.T) {
+	a := assert.New(t)
+
+	httpReq, _ := http.NewRequest("POST", "/", nil)
+	httpReq.Header.Set("Content-Type", "application/json")
+	ravenReq := raven.NewHttp(httpReq)
+
+	hook := SentryHook{
+		HTTPRequestConfiguration: HTTPRequestConfiguration{
+			IncludeBody:  true,
+			ContentTypes: []string{"application/*"},
+		},
+	}
+
+	df := newDataField(logrus.Fields{
+		fieldHTTPRequest: ravenReq,
+		fieldHTTPBody:    []byte(`{"name":"foo"}`),
+	})
+	h, ok := hook.getHTTPRequest(df)
+	a.True(ok)
+	a.Equal(map[string]interface{}{"name": "foo"}, h.Data)
+	a.Nil(ravenReq.Data, "given *raven.Http should not be modified")
+	a.True(df.isOmit(fieldHTTPBody), "`http_request_body` should be in omitList")
+
+	hook.HTTPRequestConfiguration.IncludeBody = false
+	df = newDataField(logrus.Fields{
+		fieldHTTPRequest: ravenReq,
+		fieldHTTPBody:    `{"name":"foo"}`,
+	})
+	h, ok = hook.getHTTPRequest(df)
+	a.True(ok)
+	a.Nil(h.Data, "body should not be sent when it's disabled")
+	a.True(df.isOmit(fieldHTTPBody), "`http_request_body` should be in omitList")
+}
+
+func TestSanitizeHTTPRequest(t *testing.T) {
+	a := assert.New(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Api-Key", "abc")
+	req.Header.Set("Cookie", "session=abc; theme=dark")
+	req.Header.Set("User-Agent", "test")
+	ravenReq := raven.NewHttp(req)
+
+	tests := []struct {
+		conf            HTTPRequestConfiguration
+		expectedHeaders map[string]string
+		expectedCookies string
+		description     string
+	}{
+		{
+			HTTPRequestConfiguration{
+				SensitiveHeaders: defaultSensitiveHeaders,
+				SensitiveCookies: defaultSensitiveCookies,
+			},
+			map[string]string{
+				"Authorization": markerFiltered,
+				"X-Api-Key":     markerFiltered,
+				"Cookie":        "session=[Filtered]; theme=[Filtered]",
+				"User-Agent":    "test",
+				"Host":          "example.com",
+			},
+			"session=[Filtered]; theme=[Filtered]",
+			"default",
+		},
+		{
+			HTTPRequestConfiguration{
+				SensitiveHeaders: []string{"authorization"},
+				SensitiveCookies: []string{"SESSION"},
+				RemoveSensitive:  true,
+			},
+			map[string]string{
+				"X-Api-Key":  "abc",
+				"Cookie":     "theme=dark",
+				"User-Agent": "test",
+				"Host":       "example.com",
+			},
+			"theme=dark",
+			"remove sensitive",
+		},
+		{
+			HTTPRequestConfiguration{
+				SensitiveHeaders: defaultSensitiveHeaders,
+				AllowedHeaders:   []string{"user-agent", "Authorization"},
+			},
+			map[string]string{
+				"Authorization": markerFiltered,
+				"User-Agent":    "test",
+			},
+			"",
+			"allowlist",
+		},
+		{
+			HTTPRequestConfiguration{
+				SensitiveCookies: []string{"session"},
+				AllowedHeaders:   []string{"user-agent", "cookie"},
+			},
+			map[string]string{
+				"Cookie":     "session=[Filtered]; theme=dark",
+				"User-Agent": "test",
+			},
+			"session=[Filtered]; theme=dark",
+			"allowlist with cookies",
+		},
+		{
+			HTTPRequestConfiguration{},
+			ravenReq.Headers,
+			"session=abc; theme=dark",
+			"disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+
+		h := tt.conf.sanitize(ravenReq)
+		a.Equal(tt.expectedHeaders, h.Headers, target)
+		a.Equal(tt.expectedCookies, h.Cookies, target)
+	}
+	a.Equal("Bearer abc", ravenReq.Headers["Authorization"], "original http interface should not be modified")
+}
@@ -0,0 +1,265 @@
+package logrus_sentry
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	markerTruncated = "[truncated]"
+	markerCircular  = "[circular]"
+)
+
+// NormalizeConfiguration allows for configuring the normalization of extra data.
+// Zero values of the limits mean no limit.
+type NormalizeConfiguration struct {
+	// whether extra data should be normalized
+	Enable bool
+	// the maximum depth of nested maps, slices and structs.
+	// deeper values are replaced with "[truncated]"
+	MaxDepth int
+	// the maximum number of items in a map, slice or struct.
+	// the rest of the items are replaced with "[truncated]"
+	MaxBreadth int
+	// the maximum length of strings in bytes
+	MaxStringLength int
+	// the encoding of []byte values, BytesEncodingBase64 or BytesEncodingHex.
+	// base64 is used when it's empty.
+	BytesEncoding string
+}
+
+// normalize converts value into maps, slices and primitives within the limits.
+// Values which can't be encoded into JSON are converted into strings.
+func (conf *NormalizeConfiguration) normalize(value interface{}) (result interface{}) {
+	defer func() {
+		// a panic in String() or MarshalJSON() must not lose the event
+		if r := recover(); r != nil {
+			result = fmt.Sprintf("[unserializable: %v]", r)
+		}
+	}()
+
+	n := &normalizer{
+		conf:    conf,
+		visited: make(map[visitKey]struct{}),
+	}
+	return n.normalize(reflect.ValueOf(value), 0)
+}
+
+// encode converts a value which can't be encoded into JSON the same as
+// normalize, without the limits. Other values are returned as they are.
+// It's used when normalization is disabled.
+func (conf *NormalizeConfiguration) encode(value interface{}) interface{} {
+	if isMarshalable(value) {
+		return value
+	}
+	return (&NormalizeConfiguration{BytesEncoding: conf.BytesEncoding}).normalize(value)
+}
+
+// isMarshalable reports whether value can be encoded into JSON.
+func isMarshalable(value interface{}) (ok bool) {
+	defer func() {
+		// a panic in MarshalJSON() is recovered by normalize
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+	_, err := json.Marshal(value)
+	return err == nil
+}
+
+type normalizer struct {
+	conf *NormalizeConfiguration
+	// pointers on the current path, to detect cycles
+	visited map[visitKey]struct{}
+}
+
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+func (n *normalizer) normalize(v reflect.Value, depth int) interface{} {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	if encoded, ok := n.encode(v); ok {
+		return encoded
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if n.enter(v) {
+			return markerCircular
+		}
+		defer n.leave(v)
+		return n.normalize(v.Elem(), depth)
+	case reflect.Map:
+		if n.isTooDeep(depth) {
+			return markerTruncated
+		}
+		if n.enter(v) {
+			return markerCircular
+		}
+		defer n.leave(v)
+		return n.normalizeMap(v, depth)
+	case reflect.Slice, reflect.Array:
+		if n.isTooDeep(depth) {
+			return markerTruncated
+		}
+		if v.Kind() == reflect.Slice {
+			if n.enter(v) {
+				return markerCircular
+			}
+			defer n.leave(v)
+		}
+		return n.normalizeSlice(v, depth)
+	case reflect.Struct:
+		if n.isTooDeep(depth) {
+			return markerTruncated
+		}
+		return n.normalizeStruct(v, depth)
+	}
+
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return fmt.Sprint(v)
+}
+
+func (n *normalizer) normalizeMap(v reflect.Value, depth int) interface{} {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		entries = append(entries, entry{formatMapKey(k), v.MapIndex(k)})
+	}
+	// sort keys so the same items are kept when the map is truncated
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	result := make(map[string]interface{}, len(entries))
+	for i, e := range entries {
+		if n.isTooBroad(i) {
+			result[markerTruncated] = fmt.Sprintf("%d more items", len(entries)-i)
+			break
+		}
+		result[e.key] = n.normalize(e.value, depth+1)
+	}
+	return result
+}
+
+func (n *normalizer) normalizeSlice(v reflect.Value, depth int) interface{} {
+	result := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if n.isTooBroad(i) {
+			result = append(result, markerTruncated)
+			break
+		}
+		result = append(result, n.normalize(v.Index(i), depth+1))
+	}
+	return result
+}
+
+func (n *normalizer) normalizeStruct(v reflect.Value, depth int) interface{} {
+	result := make(map[string]interface{}, v.NumField())
+	n.appendStructFields(result, v, depth)
+	return result
+}
+
+// appendStructFields adds exported fields into result using the names of
+// json tags. Fields of embedded structs are promoted like encoding/json does.
+func (n *normalizer) appendStructFields(result map[string]interface{}, v reflect.Value, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue // unexported field
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		} else if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			n.appendStructFields(result, v.Field(i), depth)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // unexported embedded field
+		}
+
+		if n.isTooBroad(len(result)) {
+			result[markerTruncated] = fmt.Sprintf("%d more fields", t.NumField()-i)
+			return
+		}
+		result[name] = n.normalize(v.Field(i), depth+1)
+	}
+}
+
+func (n *normalizer) truncateString(s string) string {
+	max := n.conf.MaxStringLength
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	// do not cut a multibyte character in half
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max] + markerTruncated
+}
+
+func (n *normalizer) isTooDeep(depth int) bool {
+	return n.conf.MaxDepth > 0 && depth >= n.conf.MaxDepth
+}
+
+func (n *normalizer) isTooBroad(index int) bool {
+	return n.conf.MaxBreadth > 0 && index >= n.conf.MaxBreadth
+}
+
+// enter marks the pointer as visited and reports whether it was already on the path.
+func (n *normalizer) enter(v reflect.Value) bool {
+	key := visitKey{v.Pointer(), v.Type()}
+	if _, ok := n.visited[key]; ok {
+		return true
+	}
+	n.visited[key] = struct{}{}
+	return false
+}
+
+func (n *normalizer) leave(v reflect.Value) {
+	delete(n.visited, visitKey{v.Pointer(), v.Type()})
+}
+
+func formatMapKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if k.CanInterface() {
+		if s, ok := formatData(k.Interface()).(string); ok {
+			return s
+		}
+	}
+	return fmt.Sprint(k)
+}
@@ -0,0 +1,115 @@
+package logrus_sentry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type normalizeNode struct {
+	Name     string         `json:"name"`
+	Next     *normalizeNode `json:"next,omitempty"`
+	Ignored  string         `json:"-"`
+	internal string
+}
+
+type normalizeEmbedded struct {
+	normalizeInner
+	Outer string
+}
+
+type normalizeInner struct {
+	Inner string
+}
+
+func TestNormalize(t *testing.T) {
+	a := assert.New(t)
+
+	conf := &NormalizeConfiguration{
+		Enable:          true,
+		MaxDepth:        2,
+		MaxBreadth:      3,
+		MaxStringLength: 5,
+	}
+
+	cyclic := &normalizeNode{Name: "a"}
+	cyclic.Next = &normalizeNode{Name: "b", Next: cyclic}
+
+	tests := []struct {
+		value       interface{}
+		expected    interface{}
+		description string
+	}{
+		{nil, nil, "nil"},
+		{13, 13, "int"},
+		{"foo", "foo", "short string"},
+		{"foobarbaz", "fooba[truncated]", "long string"},
+		{"ああ", "あ[truncated]", "multibyte string"},
+		{errors.New("error message"), "error[truncated]", "error"},
+		{myMarshaler{}, myMarshaler{}, "json marshaler"},
+		{[]int{1, 2, 3, 4}, []interface{}{1, 2, 3, markerTruncated}, "broad slice"},
+		{map[string]int{"d": 4, "a": 1, "c": 3, "b": 2}, map[string]interface{}{
+			"a": 1, "b": 2, "c": 3, markerTruncated: "1 more items",
+		}, "broad map"},
+		{map[int]string{1: "a"}, map[string]interface{}{"1": "a"}, "map with int key"},
+		{[][][]int{{{1}}}, []interface{}{[]interface{}{markerTruncated}}, "deep slice"},
+		{&normalizeNode{Name: "n", Ignored: "x", internal: "x"}, map[string]interface{}{"name": "n", "next": nil}, "struct"},
+		{normalizeEmbedded{normalizeInner{"i"}, "o"}, map[string]interface{}{"Inner": "i", "Outer": "o"}, "embedded struct"},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+		a.Equal(tt.expected, conf.normalize(tt.value), target)
+	}
+
+	conf.MaxDepth = 0
+	result := conf.normalize(cyclic)
+	a.Equal(map[string]interface{}{
+		"name": "a",
+		"next": map[string]interface{}{
+			"name": "b",
+			"next": markerCircular,
+		},
+	}, result, "cyclic pointer")
+	_, err := json.Marshal(result)
+	a.NoError(err, "normalized value should be marshaled")
+
+	cyclicMap := map[string]interface{}{}
+	cyclicMap["self"] = cyclicMap
+	a.Equal(map[string]interface{}{"self": markerCircular}, conf.normalize(cyclicMap), "cyclic map")
+
+	// the same pointer can appear more than once when it's not a cycle
+	shared := &normalizeNode{Name: "s"}
+	a.Equal([]interface{}{
+		map[string]interface{}{"name": "s", "next": nil},
+		map[string]interface{}{"name": "s", "next": nil},
+	}, conf.normalize([]*normalizeNode{shared, shared}), "shared pointer")
+}
+
+func TestFormatExtraDataNormalize(t *testing.T) {
+	a := assert.New(t)
+
+	hook := SentryHook{
+		ignoreFields: make(map[string]struct{}),
+		extraFilters: make(map[string]func(interface{}) interface{}),
+		NormalizeConfiguration: NormalizeConfiguration{
+			Enable:          true,
+			MaxStringLength: 3,
+		},
+	}
+	hook.AddExtraFilter("filtered", func(v interface{}) interface{} {
+		return strings.Repeat("x", 10)
+	})
+
+	df := newDataField(map[string]interface{}{
+		"long":     "foobar",
+		"filtered": "foo",
+	})
+	result := hook.formatExtraData(df)
+	a.Equal("foo[truncated]", result["long"])
+	a.Equal("xxx[truncated]", result["filtered"], "filtered value should be normalized")
+}
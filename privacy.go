@@ -0,0 +1,124 @@
+package logrus_sentry
+
+import (
+	"net"
+	"strings"
+
+	"github.com/getsentry/raven-go"
+)
+
+// the headers which contain the IP address of the client
+var ipHeaders = []string{
+	"X-Forwarded-For",
+	"X-Real-Ip",
+	"X-Client-Ip",
+	"Cf-Connecting-Ip",
+	"True-Client-Ip",
+}
+
+// PrivacyConfiguration allows for configuring the anonymization of user data.
+type PrivacyConfiguration struct {
+	// whether IP addresses of the user and http request are truncated.
+	// the last octet of IPv4 and all but the first 48 bits of IPv6 are zeroed.
+	AnonymizeIP bool
+	// whether the email of the user is replaced with its keyed hash
+	HashEmail bool
+	// whether the id of the user is replaced with its keyed hash
+	HashID bool
+	// the key of HMAC-SHA256 used to hash user data.
+	// the same key must be kept to correlate events of the same user.
+	// a random key of the process is used when it's empty
+	HashKey []byte
+	// whether the remote address is removed from the http request
+	DropRemoteAddr bool
+}
+
+func (conf *PrivacyConfiguration) anonymizePacket(packet *raven.Packet) {
+	if !conf.AnonymizeIP && !conf.HashEmail && !conf.HashID && !conf.DropRemoteAddr {
+		return
+	}
+
+	for i, inter := range packet.Interfaces {
+		switch v := inter.(type) {
+		case *User:
+			packet.Interfaces[i] = conf.anonymizeUser(v)
+		case *raven.Http:
+			packet.Interfaces[i] = conf.anonymizeHTTP(v)
+		}
+	}
+}
+
+// anonymizeUser returns an anonymized copy of user.
+func (conf *PrivacyConfiguration) anonymizeUser(user *User) *User {
+	u := *user
+	if conf.AnonymizeIP {
+		u.IP = anonymizeIP(u.IP)
+	}
+	if conf.HashEmail && u.Email != "" {
+		u.Email = hashValue(conf.HashKey, u.Email)
+	}
+	if conf.HashID && u.ID != "" {
+		u.ID = hashValue(conf.HashKey, u.ID)
+	}
+	return &u
+}
+
+// anonymizeHTTP returns an anonymized copy of h.
+func (conf *PrivacyConfiguration) anonymizeHTTP(h *raven.Http) *raven.Http {
+	req := *h
+	if conf.DropRemoteAddr && req.Env != nil {
+		req.Env = make(map[string]string, len(h.Env))
+		for k, v := range h.Env {
+			if k == "REMOTE_ADDR" || k == "REMOTE_PORT" {
+				continue
+			}
+			req.Env[k] = v
+		}
+	}
+
+	if !conf.AnonymizeIP {
+		return &req
+	}
+	if addr, ok := req.Env["REMOTE_ADDR"]; ok {
+		env := make(map[string]string, len(req.Env))
+		for k, v := range req.Env {
+			env[k] = v
+		}
+		env["REMOTE_ADDR"] = anonymizeIP(addr)
+		req.Env = env
+	}
+	if req.Headers != nil {
+		headers := make(map[string]string, len(h.Headers))
+		for k, v := range req.Headers {
+			if containsFold(ipHeaders, k) {
+				v = anonymizeIPList(v)
+			}
+			headers[k] = v
+		}
+		req.Headers = headers
+	}
+	return &req
+}
+
+// anonymizeIP zeroes the last octet of IPv4 and all but the first 48 bits
+// of IPv6. Values which are not IP addresses are returned as they are.
+func anonymizeIP(s string) string {
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return s
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// anonymizeIPList anonymizes a comma separated list of IP addresses,
+// such as X-Forwarded-For.
+func anonymizeIPList(s string) string {
+	ips := strings.Split(s, ",")
+	for i := range ips {
+		ips[i] = anonymizeIP(strings.TrimSpace(ips[i]))
+	}
+	return strings.Join(ips, ", ")
+}
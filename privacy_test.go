@@ -0,0 +1,110 @@
+package logrus_sentry
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"192.168.1.123", "192.168.1.0"},
+		{" 10.0.0.1 ", "10.0.0.0"},
+		{"2001:db8:85a3:8d3:1319:8a2e:370:7348", "2001:db8:85a3::"},
+		{"::ffff:192.168.1.123", "192.168.1.0"},
+		{"{{auto}}", "{{auto}}"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+		a.Equal(tt.expected, anonymizeIP(tt.value), target)
+	}
+
+	a.Equal("203.0.113.0, 10.0.0.0", anonymizeIPList("203.0.113.7,10.0.0.1"))
+}
+
+func TestAnonymizePacket(t *testing.T) {
+	a := assert.New(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	req.RemoteAddr = "192.168.1.123:5000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	ravenReq := raven.NewHttp(req)
+	user := &User{User: raven.User{
+		ID:    "1001",
+		Email: "foo@example.com",
+		IP:    "192.168.1.123",
+	}}
+
+	conf := &PrivacyConfiguration{
+		AnonymizeIP: true,
+		HashEmail:   true,
+		HashID:      true,
+		HashKey:     []byte("key"),
+	}
+	packet := raven.NewPacket(message, ravenReq, user)
+	conf.anonymizePacket(packet)
+
+	h := packet.Interfaces[0].(*raven.Http)
+	a.Equal("192.168.1.0", h.Env["REMOTE_ADDR"])
+	a.Equal("5000", h.Env["REMOTE_PORT"])
+	a.Equal("203.0.113.0", h.Headers["X-Forwarded-For"])
+	a.Equal("192.168.1.123", ravenReq.Env["REMOTE_ADDR"], "original http interface should not be modified")
+
+	u := packet.Interfaces[1].(*User)
+	a.Equal("192.168.1.0", u.IP)
+	a.Equal(hashValue([]byte("key"), "foo@example.com"), u.Email)
+	a.Equal(hashValue([]byte("key"), "1001"), u.ID)
+	a.NotEqual(hashValue([]byte("other"), "1001"), u.ID, "hash should depend on the key")
+	a.Equal("1001", user.ID, "original user should not be modified")
+
+	conf = &PrivacyConfiguration{DropRemoteAddr: true}
+	packet = raven.NewPacket(message, ravenReq)
+	conf.anonymizePacket(packet)
+	h = packet.Interfaces[0].(*raven.Http)
+	a.Empty(h.Env)
+	a.Equal("203.0.113.7", h.Headers["X-Forwarded-For"])
+}
+
+func TestPrivacyUserFields(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.PrivacyConfiguration = PrivacyConfiguration{
+			AnonymizeIP: true,
+			HashEmail:   true,
+			HashID:      true,
+			HashKey:     []byte("key"),
+		}
+		logger.Hooks.Add(hook)
+
+		logger.WithFields(logrus.Fields{
+			"user_id":    "1001",
+			"user_email": "foo@example.com",
+			"user_ip":    "192.168.1.123",
+		}).Error(message)
+		packet := <-pch
+		assert.Equal(t, hashValue([]byte("key"), "1001"), packet.User.ID)
+		assert.Equal(t, "192.168.1.0", packet.User.IP)
+
+		// the raw values are not sent as extra data
+		assert.NotContains(t, packet.Extra, "user_id")
+		assert.NotContains(t, packet.Extra, "user_email")
+		assert.NotContains(t, packet.Extra, "user_ip")
+	})
+}
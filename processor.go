@@ -0,0 +1,143 @@
+package logrus_sentry
+
+import (
+	"fmt"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+)
+
+// names of the built-in stages of the event processor pipeline, in the order
+// they are processed
+const (
+	StageFields      = "fields"
+	StageTags        = "tags"
+	StageHTTP        = "http"
+	StageUser        = "user"
+	StageStacktrace  = "stacktrace"
+	StageBreadcrumbs = "breadcrumbs"
+	StageScope       = "scope"
+	StageDefaults    = "defaults"
+	StageTrace       = "trace"
+	StageExtra       = "extra"
+	StageURL         = "url"
+	StageSecrets     = "secrets"
+	StagePrivacy     = "privacy"
+	StageScrub       = "scrub"
+)
+
+// Event is an event being built from a log entry by the event processors.
+type Event struct {
+	// the log entry of the event
+	Entry *logrus.Entry
+	// the packet which will be sent to sentry
+	Packet *raven.Packet
+
+	hook *SentryHook
+	df   *dataField
+	// the stacktrace of the caller, taken in Fire
+	stacktrace *raven.Stacktrace
+	// whether the event is a transaction, which has no breadcrumbs and
+	// fingerprint
+	transaction bool
+}
+
+// UseField returns the value of an entry field, and marks it as used so it
+// is not sent as extra data.
+func (e *Event) UseField(key string) (interface{}, bool) {
+	v, ok := e.df.data[key]
+	if ok {
+		e.df.omitList[key] = struct{}{}
+	}
+	return v, ok
+}
+
+// EventProcessor processes an event before it is sent.
+type EventProcessor interface {
+	// Process modifies the event, or returns nil to drop it.
+	Process(event *Event) *Event
+}
+
+// EventProcessorFunc is an adapter to use a function as an EventProcessor.
+type EventProcessorFunc func(event *Event) *Event
+
+// Process calls f(event).
+func (f EventProcessorFunc) Process(event *Event) *Event {
+	return f(event)
+}
+
+type eventStage struct {
+	// the name of the built-in stage, empty for added processors
+	name      string
+	processor EventProcessor
+	// the stage which the processor was added after
+	after string
+}
+
+func defaultEventStages() []eventStage {
+	return []eventStage{
+		{name: StageFields, processor: EventProcessorFunc(processFields)},
+		{name: StageTags, processor: EventProcessorFunc(processTags)},
+		{name: StageHTTP, processor: EventProcessorFunc(processHTTP)},
+		{name: StageUser, processor: EventProcessorFunc(processUser)},
+		{name: StageStacktrace, processor: EventProcessorFunc(processStacktrace)},
+		{name: StageBreadcrumbs, processor: EventProcessorFunc(processBreadcrumbs)},
+		{name: StageScope, processor: EventProcessorFunc(processScope)},
+		{name: StageDefaults, processor: EventProcessorFunc(processDefaults)},
+		{name: StageTrace, processor: EventProcessorFunc(processTrace)},
+		{name: StageExtra, processor: EventProcessorFunc(processExtra)},
+		{name: StageURL, processor: EventProcessorFunc(processURL)},
+		{name: StageSecrets, processor: EventProcessorFunc(processSecrets)},
+		{name: StagePrivacy, processor: EventProcessorFunc(processPrivacy)},
+		{name: StageScrub, processor: EventProcessorFunc(processScrub)},
+	}
+}
+
+// AddEventProcessor adds an event processor at the end of the pipeline.
+func (hook *SentryHook) AddEventProcessor(p EventProcessor) {
+	hook.stages = append(hook.stages, eventStage{processor: p})
+}
+
+// AddEventProcessorBefore adds an event processor right before the named
+// built-in stage.
+func (hook *SentryHook) AddEventProcessorBefore(stage string, p EventProcessor) error {
+	return hook.insertEventProcessor(stage, false, p)
+}
+
+// AddEventProcessorAfter adds an event processor right after the named
+// built-in stage, and the processors already added after it.
+func (hook *SentryHook) AddEventProcessorAfter(stage string, p EventProcessor) error {
+	return hook.insertEventProcessor(stage, true, p)
+}
+
+func (hook *SentryHook) insertEventProcessor(stage string, after bool, p EventProcessor) error {
+	for i, s := range hook.stages {
+		if stage == "" || s.name != stage {
+			continue
+		}
+		added := eventStage{processor: p}
+		if after {
+			added.after = stage
+			i++
+			// keep the order of the processors added after the same stage
+			for i < len(hook.stages) && hook.stages[i].after == stage {
+				i++
+			}
+		}
+		hook.stages = append(hook.stages, eventStage{})
+		copy(hook.stages[i+1:], hook.stages[i:])
+		hook.stages[i] = added
+		return nil
+	}
+	return fmt.Errorf("unknown event processor stage: %s", stage)
+}
+
+// processEvent runs the pipeline. It returns nil when the event is dropped.
+func (hook *SentryHook) processEvent(ev *Event) *Event {
+	for _, s := range hook.stages {
+		if ev = s.processor.Process(ev); ev == nil {
+			return nil
+		}
+	}
+	return ev
+}
@@ -0,0 +1,97 @@
+package logrus_sentry
+
+import (
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func stageNames(hook *SentryHook) []string {
+	names := make([]string, len(hook.stages))
+	for i, s := range hook.stages {
+		names[i] = s.name
+		if s.name == "" {
+			names[i] = string(s.processor.(namedTestProcessor))
+		}
+	}
+	return names
+}
+
+type namedTestProcessor string
+
+func (namedTestProcessor) Process(ev *Event) *Event { return ev }
+
+func TestAddEventProcessor(t *testing.T) {
+	a := assert.New(t)
+
+	hook := &SentryHook{
+		stages: []eventStage{
+			{name: StageTags},
+			{name: StageUser},
+			{name: StageExtra},
+		},
+	}
+
+	hook.AddEventProcessor(namedTestProcessor("last"))
+	a.NoError(hook.AddEventProcessorBefore(StageTags, namedTestProcessor("before_tags")))
+	a.NoError(hook.AddEventProcessorAfter(StageTags, namedTestProcessor("after_tags_1")))
+	a.NoError(hook.AddEventProcessorAfter(StageTags, namedTestProcessor("after_tags_2")))
+	a.NoError(hook.AddEventProcessorBefore(StageExtra, namedTestProcessor("before_extra")))
+	a.NoError(hook.AddEventProcessorAfter(StageExtra, namedTestProcessor("after_extra")))
+	a.Error(hook.AddEventProcessorAfter("unknown", namedTestProcessor("unknown")))
+
+	a.Equal([]string{
+		"before_tags",
+		StageTags,
+		"after_tags_1",
+		"after_tags_2",
+		StageUser,
+		"before_extra",
+		StageExtra,
+		"after_extra",
+		"last",
+	}, stageNames(hook))
+}
+
+func TestEventProcessor(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		a := assert.New(t)
+
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		a.NoError(err, "NewSentryHook should be NoError")
+		logger.Hooks.Add(hook)
+
+		// the field used by a processor before the extra stage is not sent as extra data
+		err = hook.AddEventProcessorBefore(StageExtra, EventProcessorFunc(func(ev *Event) *Event {
+			if v, ok := ev.UseField("request_id"); ok {
+				ev.Packet.Tags = append(ev.Packet.Tags, raven.Tag{Key: "request_id", Value: v.(string)})
+			}
+			return ev
+		}))
+		a.NoError(err)
+		hook.AddEventProcessor(EventProcessorFunc(func(ev *Event) *Event {
+			if _, ok := ev.Entry.Data["drop"]; ok {
+				return nil
+			}
+			return ev
+		}))
+
+		logger.WithField("request_id", "abc").Error(message)
+		packet := <-pch
+		a.Equal(raven.Tags{{Key: "request_id", Value: "abc"}}, packet.Tags)
+		a.NotContains(packet.Extra, "request_id")
+
+		logger.WithField("drop", true).Error(message)
+		select {
+		case <-pch:
+			t.Error("dropped event should not be sent")
+		default:
+		}
+		a.Equal(uint64(1), hook.Stats().Dropped)
+	})
+}
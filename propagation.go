@@ -0,0 +1,318 @@
+package logrus_sentry
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerSentryTrace = "sentry-trace"
+	headerBaggage     = "baggage"
+	headerTraceparent = "traceparent"
+
+	baggageSentryPrefix = "sentry-"
+)
+
+var (
+	sentryTracePattern = regexp.MustCompile(`^[ \t]*([0-9a-f]{32})-([0-9a-f]{16})(?:-([01]))?[ \t]*$`)
+	traceparentPattern = regexp.MustCompile(`^[ \t]*([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(?:-.*)?[ \t]*$`)
+)
+
+type propagationKey struct{}
+
+// propagation is the trace continued from the headers of an incoming request.
+type propagation struct {
+	// the span of this service, whose parent is the span of the headers
+	span SpanContext
+	// whether the sampling is decided by the headers
+	sampleDecided bool
+	// the dynamic sampling context of the "sentry-" members of baggage
+	dsc map[string]string
+	// the other members of baggage, which are propagated as they are
+	baggage []string
+}
+
+// ContinueTrace returns a copy of ctx which continues the trace of the
+// sentry-trace or traceparent header, and the dynamic sampling context of the
+// baggage header. A new trace is started when there is no valid header.
+func ContinueTrace(ctx context.Context, h http.Header) context.Context {
+	p := &propagation{}
+	parent, decided, ok := parseSentryTrace(h.Get(headerSentryTrace))
+	if !ok {
+		parent, decided, ok = parseTraceparent(h.Get(headerTraceparent))
+	}
+	if ok {
+		p.span = SpanContext{
+			TraceID:      parent.TraceID,
+			SpanID:       newSpanID(),
+			ParentSpanID: parent.SpanID,
+			Sampled:      parent.Sampled,
+		}
+		p.sampleDecided = decided
+		// the dynamic sampling context belongs to the trace of the headers
+		p.dsc, p.baggage = parseBaggage(h[http.CanonicalHeaderKey(headerBaggage)])
+	} else {
+		p.span = SpanContext{
+			TraceID: newTraceID(),
+			SpanID:  newSpanID(),
+		}
+		_, p.baggage = parseBaggage(h[http.CanonicalHeaderKey(headerBaggage)])
+	}
+	return context.WithValue(ctx, propagationKey{}, p)
+}
+
+// TraceMiddleware wraps an http.Handler so that each request continues the
+// trace of its headers in its context.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ContinueTrace(r.Context(), r.Header)))
+	})
+}
+
+func propagationFromContext(ctx context.Context) (*propagation, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	p, ok := ctx.Value(propagationKey{}).(*propagation)
+	return p, ok
+}
+
+// parseSentryTrace parses "<trace_id>-<span_id>-<sampled>", whose sampled is
+// optional.
+func parseSentryTrace(v string) (sc SpanContext, decided, ok bool) {
+	m := sentryTracePattern.FindStringSubmatch(v)
+	if m == nil {
+		return sc, false, false
+	}
+	sc = SpanContext{
+		TraceID: m[1],
+		SpanID:  m[2],
+		Sampled: m[3] == "1",
+	}
+	return sc, m[3] != "", true
+}
+
+// parseTraceparent parses "<version>-<trace_id>-<span_id>-<flags>" of W3C
+// Trace Context.
+func parseTraceparent(v string) (sc SpanContext, decided, ok bool) {
+	m := traceparentPattern.FindStringSubmatch(v)
+	if m == nil || m[1] == "ff" || strings.Trim(m[2], "0") == "" || strings.Trim(m[3], "0") == "" {
+		return sc, false, false
+	}
+	flags, _ := strconv.ParseUint(m[4], 16, 8)
+	sc = SpanContext{
+		TraceID: m[2],
+		SpanID:  m[3],
+		Sampled: flags&1 == 1,
+	}
+	return sc, true, true
+}
+
+// parseBaggage returns the "sentry-" members of baggage headers as the
+// dynamic sampling context, and the other members as they are.
+func parseBaggage(headers []string) (map[string]string, []string) {
+	var dsc map[string]string
+	var others []string
+	for _, h := range headers {
+		for _, member := range strings.Split(h, ",") {
+			member = strings.TrimSpace(member)
+			if member == "" {
+				continue
+			}
+			if !strings.HasPrefix(member, baggageSentryPrefix) {
+				others = append(others, member)
+				continue
+			}
+			kv := member
+			if i := strings.Index(kv, ";"); i >= 0 {
+				// properties are not used
+				kv = kv[:i]
+			}
+			eq := strings.Index(kv, "=")
+			if eq < 0 {
+				continue
+			}
+			key := strings.TrimSpace(kv[len(baggageSentryPrefix):eq])
+			value, err := url.PathUnescape(strings.TrimSpace(kv[eq+1:]))
+			if err != nil {
+				continue
+			}
+			if dsc == nil {
+				dsc = make(map[string]string)
+			}
+			dsc[key] = value
+		}
+	}
+	return dsc, others
+}
+
+// formatBaggage returns the baggage header of the dynamic sampling context
+// and the other members.
+func formatBaggage(dsc map[string]string, others []string) string {
+	keys := make([]string, 0, len(dsc))
+	for k := range dsc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	members := make([]string, 0, len(keys)+len(others))
+	for _, k := range keys {
+		members = append(members, baggageSentryPrefix+k+"="+url.PathEscape(dsc[k]))
+	}
+	members = append(members, others...)
+	return strings.Join(members, ",")
+}
+
+// sampled returns the sampling decision of the trace of ctx for a new
+// transaction.
+func (hook *SentryHook) sampled(ctx context.Context) (sampled, decided bool) {
+	if span, ok := SpanFromContext(ctx); ok {
+		return span.sampled, true
+	}
+	if p, ok := propagationFromContext(ctx); ok {
+		return p.span.Sampled, p.sampleDecided
+	}
+	if sc, ok := hook.TraceConfiguration.spanContext(ctx); ok {
+		return sc.Sampled, true
+	}
+	return false, false
+}
+
+// dynamicSamplingContext returns the dynamic sampling context of the trace of
+// sc. The context of the transaction or the headers is used as it is,
+// otherwise it's created by this service, which is the head of the trace.
+func (hook *SentryHook) dynamicSamplingContext(ctx context.Context, sc SpanContext) map[string]string {
+	if dsc, ok := traceDynamicSamplingContext(ctx, sc.TraceID); ok {
+		return dsc
+	}
+	return hook.newDynamicSamplingContext(sc.TraceID, nil)
+}
+
+// traceDynamicSamplingContext returns the dynamic sampling context which the
+// trace of ctx already has, i.e. the one of the current transaction or the one
+// continued from the headers.
+func traceDynamicSamplingContext(ctx context.Context, traceID string) (map[string]string, bool) {
+	if span, ok := SpanFromContext(ctx); ok && span.traceID == traceID && len(span.tx.dsc) != 0 {
+		return span.tx.dsc, true
+	}
+	if p, ok := propagationFromContext(ctx); ok && p.span.TraceID == traceID && len(p.dsc) != 0 {
+		return p.dsc, true
+	}
+	return nil, false
+}
+
+// newDynamicSamplingContext returns the dynamic sampling context of a trace
+// started by this service, with the transaction and sampling decision of tx
+// if any.
+func (hook *SentryHook) newDynamicSamplingContext(traceID string, tx *transaction) map[string]string {
+	root := hook.root()
+	root.dscMu.RLock()
+	publicKey, environment := root.publicKey, root.environment
+	root.dscMu.RUnlock()
+
+	dsc := map[string]string{
+		"trace_id": traceID,
+	}
+	if publicKey != "" {
+		dsc["public_key"] = publicKey
+	}
+	if hook.client != nil {
+		if release := hook.client.Release(); release != "" {
+			dsc["release"] = release
+		}
+	}
+	if environment != "" {
+		dsc["environment"] = environment
+	}
+	if tx != nil {
+		dsc["transaction"] = tx.name
+		dsc["sampled"] = strconv.FormatBool(tx.root.sampled)
+		dsc["sample_rate"] = strconv.FormatFloat(hook.TracingConfiguration.SampleRate, 'f', -1, 64)
+	}
+	return dsc
+}
+
+// publicKeyOfDSN returns the public key of the DSN, or "" when it's invalid.
+func publicKeyOfDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		return ""
+	}
+	return u.User.Username()
+}
+
+// TraceTransport is an http.RoundTripper which propagates the trace of the
+// request context by the sentry-trace, traceparent and baggage headers.
+// It's created by NewTraceTransport, and the zero value only sends requests.
+type TraceTransport struct {
+	// the transport which sends requests. http.DefaultTransport is used when
+	// it's nil
+	Base http.RoundTripper
+
+	hook *SentryHook
+}
+
+// NewTraceTransport returns a TraceTransport propagating the traces of the
+// hook.
+func (hook *SentryHook) NewTraceTransport(base http.RoundTripper) *TraceTransport {
+	return &TraceTransport{
+		Base: base,
+		hook: hook,
+	}
+}
+
+// RoundTrip adds the headers of the trace to a copy of the request, and sends
+// it by Base.
+func (t *TraceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if t.hook == nil {
+		return base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	sc, ok := t.hook.TraceConfiguration.spanContext(ctx)
+	if !ok {
+		return base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the request
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+3)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+
+	sampled, decided := t.hook.sampled(ctx)
+	sentryTrace := sc.TraceID + "-" + sc.SpanID
+	flags := "00"
+	if decided {
+		if sampled {
+			sentryTrace += "-1"
+			flags = "01"
+		} else {
+			sentryTrace += "-0"
+		}
+	}
+	r.Header.Set(headerSentryTrace, sentryTrace)
+	r.Header.Set(headerTraceparent, "00-"+sc.TraceID+"-"+sc.SpanID+"-"+flags)
+
+	// the sentry members of baggage are replaced, and the others are kept
+	_, others := parseBaggage(req.Header[http.CanonicalHeaderKey(headerBaggage)])
+	if p, ok := propagationFromContext(ctx); ok && len(others) == 0 {
+		others = p.baggage
+	}
+	dsc := t.hook.dynamicSamplingContext(ctx, sc)
+	r.Header.Set(headerBaggage, formatBaggage(dsc, others))
+
+	return base.RoundTrip(r)
+}
@@ -0,0 +1,255 @@
+package logrus_sentry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSentryTrace(t *testing.T) {
+	tests := []struct {
+		value   string
+		ok      bool
+		sampled bool
+		decided bool
+	}{
+		{testTraceID + "-" + testSpanID + "-1", true, true, true},
+		{testTraceID + "-" + testSpanID + "-0", true, false, true},
+		{" " + testTraceID + "-" + testSpanID + " ", true, false, false},
+		{testTraceID + "-" + testSpanID + "-2", false, false, false},
+		{testTraceID + "-" + testSpanID[1:], false, false, false},
+		{"", false, false, false},
+	}
+
+	for _, tt := range tests {
+		target := tt.value
+		sc, decided, ok := parseSentryTrace(tt.value)
+		assert.Equal(t, tt.ok, ok, target)
+		if !ok {
+			continue
+		}
+		assert.Equal(t, testTraceID, sc.TraceID, target)
+		assert.Equal(t, testSpanID, sc.SpanID, target)
+		assert.Equal(t, tt.sampled, sc.Sampled, target)
+		assert.Equal(t, tt.decided, decided, target)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value   string
+		ok      bool
+		sampled bool
+	}{
+		{"00-" + testTraceID + "-" + testSpanID + "-01", true, true},
+		{"00-" + testTraceID + "-" + testSpanID + "-00", true, false},
+		{"01-" + testTraceID + "-" + testSpanID + "-03-future", true, true},
+		{"ff-" + testTraceID + "-" + testSpanID + "-01", false, false},
+		{"00-00000000000000000000000000000000-" + testSpanID + "-01", false, false},
+		{"00-" + testTraceID + "-0000000000000000-01", false, false},
+		{"00-" + testTraceID + "-" + testSpanID, false, false},
+	}
+
+	for _, tt := range tests {
+		target := tt.value
+		sc, decided, ok := parseTraceparent(tt.value)
+		assert.Equal(t, tt.ok, ok, target)
+		if !ok {
+			continue
+		}
+		assert.True(t, decided, target)
+		assert.Equal(t, testTraceID, sc.TraceID, target)
+		assert.Equal(t, testSpanID, sc.SpanID, target)
+		assert.Equal(t, tt.sampled, sc.Sampled, target)
+	}
+}
+
+func TestBaggage(t *testing.T) {
+	a := assert.New(t)
+
+	dsc, others := parseBaggage([]string{
+		"sentry-trace_id=" + testTraceID + ",sentry-release=app%401.0.0, vendor=value;prop",
+		"sentry-public_key=key;prop,,sentry-invalid",
+	})
+	a.Equal(map[string]string{
+		"trace_id":   testTraceID,
+		"release":    "app@1.0.0",
+		"public_key": "key",
+	}, dsc)
+	a.Equal([]string{"vendor=value;prop"}, others)
+
+	a.Equal("sentry-public_key=key,sentry-release=app@1.0.0,sentry-transaction=GET%20%2Fusers,vendor=value;prop",
+		formatBaggage(map[string]string{
+			"release":     "app@1.0.0",
+			"public_key":  "key",
+			"transaction": "GET /users",
+		}, others))
+}
+
+func TestContinueTrace(t *testing.T) {
+	a := assert.New(t)
+
+	hook, err := NewSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	a.NoError(err)
+
+	h := http.Header{}
+	h.Set("sentry-trace", testTraceID+"-"+testSpanID+"-0")
+	h.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx := ContinueTrace(context.Background(), h)
+
+	// sentry-trace takes precedence over traceparent
+	sc, ok := hook.TraceConfiguration.spanContext(ctx)
+	a.True(ok)
+	a.Equal(testTraceID, sc.TraceID)
+	a.Equal(testSpanID, sc.ParentSpanID)
+	a.NotEqual(testSpanID, sc.SpanID)
+
+	// the transaction is the span of this service, and keeps the decision
+	_, tx := hook.StartTransaction(ctx, "GET /users")
+	a.Equal(sc, tx.SpanContext())
+
+	// the sampling of a new trace is decided by the sample rate
+	hook.TracingConfiguration.SampleRate = 0
+	ctx = ContinueTrace(context.Background(), http.Header{})
+	sc, ok = hook.TraceConfiguration.spanContext(ctx)
+	a.True(ok)
+	a.Len(sc.TraceID, 32)
+	a.Empty(sc.ParentSpanID)
+	_, tx = hook.StartTransaction(ctx, "GET /users")
+	a.Equal(sc.TraceID, tx.SpanContext().TraceID)
+	a.False(tx.SpanContext().Sampled)
+}
+
+func TestTraceTransport(t *testing.T) {
+	a := assert.New(t)
+
+	var got http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer s.Close()
+
+	hook, err := NewSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	a.NoError(err)
+	client := &http.Client{Transport: hook.NewTraceTransport(nil)}
+
+	var ctx context.Context
+	handler := TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+		req, _ := http.NewRequest("GET", s.URL, nil)
+		req.Header.Set("baggage", "sentry-release=other,outgoing=1")
+		resp, err := client.Do(req.WithContext(ctx))
+		if a.NoError(err) {
+			resp.Body.Close()
+		}
+		a.Equal("sentry-release=other,outgoing=1", req.Header.Get("baggage"), "the request should not be modified")
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("sentry-trace", testTraceID+"-"+testSpanID+"-1")
+	req.Header.Set("baggage", "sentry-trace_id="+testTraceID+",sentry-sample_rate=0.5,incoming=1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	sc, ok := hook.TraceConfiguration.spanContext(ctx)
+	a.True(ok)
+	a.Equal(testTraceID+"-"+sc.SpanID+"-1", got.Get("sentry-trace"))
+	a.Equal("00-"+testTraceID+"-"+sc.SpanID+"-01", got.Get("traceparent"))
+	a.Equal("sentry-sample_rate=0.5,sentry-trace_id="+testTraceID+",outgoing=1", got.Get("baggage"))
+
+	// requests without traces are sent as they are
+	_, err = client.Get(s.URL)
+	a.NoError(err)
+	a.Empty(got.Get("sentry-trace"))
+	a.Empty(got.Get("baggage"))
+}
+
+func TestDynamicSamplingContext(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.SetRelease("1.0.0")
+		hook.SetEnvironment("production")
+		logger.Hooks.Add(hook)
+
+		// the dynamic sampling context of the headers is kept
+		h := http.Header{}
+		h.Set("sentry-trace", testTraceID+"-"+testSpanID)
+		h.Set("baggage", "sentry-trace_id="+testTraceID+",sentry-release=2.0.0")
+		ctx := ContinueTrace(context.Background(), h)
+		sc, _ := hook.TraceConfiguration.spanContext(ctx)
+		assert.Equal(t, map[string]string{
+			"trace_id": testTraceID,
+			"release":  "2.0.0",
+		}, hook.dynamicSamplingContext(ctx, sc))
+
+		// it's sent in the trace context of events
+		logger.WithContext(ctx).Error("failed")
+		packet := <-pch
+		assert.NotContains(t, packet.Contexts, "dynamic_sampling_context")
+		trace := packet.Contexts["trace"].(map[string]interface{})
+		assert.Equal(t, testSpanID, trace["parent_span_id"])
+		assert.Equal(t, map[string]interface{}{
+			"trace_id": testTraceID,
+			"release":  "2.0.0",
+		}, trace["dynamic_sampling_context"])
+
+		// this service is the head of a new trace
+		ctx = ContinueTrace(context.Background(), http.Header{})
+		sc, _ = hook.TraceConfiguration.spanContext(ctx)
+		assert.Equal(t, map[string]string{
+			"trace_id":    sc.TraceID,
+			"public_key":  "public",
+			"release":     "1.0.0",
+			"environment": "production",
+		}, hook.dynamicSamplingContext(ctx, sc))
+
+		// the transaction keeps the context of its trace
+		ctx, tx := hook.StartTransaction(context.Background(), "GET /users")
+		hook.SetRelease("1.0.1")
+		ctx, span := StartSpan(ctx, "db")
+		assert.Equal(t, map[string]string{
+			"trace_id":    tx.SpanContext().TraceID,
+			"public_key":  "public",
+			"release":     "1.0.0",
+			"environment": "production",
+			"transaction": "GET /users",
+			"sampled":     "true",
+			"sample_rate": "1",
+		}, hook.dynamicSamplingContext(ctx, span.SpanContext()))
+
+		// transactions have the context too
+		span.Finish()
+		tx.Finish()
+		packet = <-pch
+		trace = packet.Contexts["trace"].(map[string]interface{})
+		assert.Equal(t, "GET /users", trace["dynamic_sampling_context"].(map[string]interface{})["transaction"])
+	})
+}
+
+func TestTraceTransportZeroValue(t *testing.T) {
+	a := assert.New(t)
+
+	var got http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer s.Close()
+
+	client := &http.Client{Transport: &TraceTransport{}}
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	ctx := ContinueTrace(context.Background(), http.Header{})
+	resp, err := client.Do(req.WithContext(ctx))
+	if a.NoError(err) {
+		resp.Body.Close()
+	}
+	a.Empty(got.Get("sentry-trace"))
+}
@@ -0,0 +1,248 @@
+package logrus_sentry
+
+import (
+	"context"
+	"sync"
+
+	"github.com/getsentry/raven-go"
+)
+
+type scopeKey struct{}
+
+// Contexts is the interface of contexts in the packet, e.g. "os", "runtime"
+// or "trace".
+type Contexts map[string]interface{}
+
+// Class returns the key of contexts in the packet.
+func (c Contexts) Class() string {
+	return "contexts"
+}
+
+// packetContexts returns the contexts of the packet, adding them when the
+// packet has none.
+func packetContexts(packet *raven.Packet) Contexts {
+	for _, inter := range packet.Interfaces {
+		if c, ok := inter.(Contexts); ok {
+			return c
+		}
+	}
+	c := Contexts{}
+	packet.Interfaces = append(packet.Interfaces, c)
+	return c
+}
+
+// Scope is the data merged into the events logged with a context which has
+// it, e.g. by logger.WithContext(ctx). The fields of entries take precedence
+// over the scope. It's safe for concurrent use.
+type Scope struct {
+	mu          sync.RWMutex
+	user        *User
+	tags        map[string]string
+	extra       map[string]interface{}
+	contexts    map[string]interface{}
+	fingerprint []string
+	level       raven.Severity
+
+	breadcrumbs breadcrumbBuffer
+}
+
+// NewScope returns an empty scope.
+func NewScope() *Scope {
+	return &Scope{}
+}
+
+// WithScope returns a copy of ctx which has the scope.
+func WithScope(ctx context.Context, scope *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope of ctx.
+func ScopeFromContext(ctx context.Context) (*Scope, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	scope, ok := ctx.Value(scopeKey{}).(*Scope)
+	return scope, ok
+}
+
+// Clone returns a copy of the scope, which can be modified independently.
+func (s *Scope) Clone() *Scope {
+	clone := s.cloneData()
+	clone.breadcrumbs.restore(s.breadcrumbs.snapshot())
+	return clone
+}
+
+// cloneData returns a copy of the scope without breadcrumbs.
+func (s *Scope) cloneData() *Scope {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clone := &Scope{
+		tags:        copyStringMap(s.tags),
+		extra:       copyMap(s.extra),
+		contexts:    copyMap(s.contexts),
+		fingerprint: append([]string(nil), s.fingerprint...),
+		level:       s.level,
+	}
+	if s.user != nil {
+		user := *s.user
+		clone.user = &user
+	}
+	return clone
+}
+
+// SetUser sets the user of events.
+func (s *Scope) SetUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = &user
+}
+
+// SetTag sets a tag of events.
+func (s *Scope) SetTag(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tags == nil {
+		s.tags = make(map[string]string)
+	}
+	s.tags[key] = value
+}
+
+// SetExtra sets a value of the extra data of events.
+func (s *Scope) SetExtra(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.extra == nil {
+		s.extra = make(map[string]interface{})
+	}
+	s.extra[key] = value
+}
+
+// SetContext sets a context of events, e.g. "app" or "device".
+func (s *Scope) SetContext(name string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.contexts == nil {
+		s.contexts = make(map[string]interface{})
+	}
+	s.contexts[name] = value
+}
+
+// SetFingerprint sets the fingerprint of events.
+func (s *Scope) SetFingerprint(fingerprint []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fingerprint = append([]string(nil), fingerprint...)
+}
+
+// SetLevel overrides the level of events.
+func (s *Scope) SetLevel(level raven.Severity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.level = level
+}
+
+// AddBreadcrumb records a breadcrumb, keeping at most limit breadcrumbs.
+func (s *Scope) AddBreadcrumb(b Breadcrumb, limit int) {
+	s.breadcrumbs.add(b, limit)
+}
+
+// ClearBreadcrumbs removes all the breadcrumbs.
+func (s *Scope) ClearBreadcrumbs() {
+	s.breadcrumbs.restore(nil)
+}
+
+// apply merges the scope into the event, keeping what the entry has set.
+func (s *Scope) apply(ev *Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	packet := ev.Packet
+	if s.level != "" {
+		packet.Level = s.level
+	}
+	if len(packet.Fingerprint) == 0 && len(s.fingerprint) != 0 {
+		packet.Fingerprint = append([]string(nil), s.fingerprint...)
+	}
+
+	tags, _ := toTags(s.tags)
+	for _, tag := range tags {
+		if !hasTag(packet.Tags, tag.Key) {
+			packet.Tags = append(packet.Tags, tag)
+		}
+	}
+
+	if s.user != nil && !hasUser(packet) {
+		user := *s.user
+		packet.Interfaces = append(packet.Interfaces, &user)
+	}
+
+	if len(s.extra) != 0 {
+		extra := ev.hook.formatExtraData(newDataField(s.extra))
+		if packet.Extra == nil {
+			packet.Extra = make(map[string]interface{}, len(extra))
+		}
+		for k, v := range extra {
+			if _, ok := packet.Extra[k]; !ok {
+				packet.Extra[k] = v
+			}
+		}
+	}
+
+	if len(s.contexts) != 0 {
+		contexts := packetContexts(packet)
+		for k, v := range s.contexts {
+			if _, ok := contexts[k]; !ok {
+				contexts[k] = v
+			}
+		}
+	}
+}
+
+func processScope(ev *Event) *Event {
+	if scope, ok := ScopeFromContext(ev.Entry.Context); ok {
+		scope.apply(ev)
+	}
+	return ev
+}
+
+func hasTag(tags raven.Tags, key string) bool {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasUser(packet *raven.Packet) bool {
+	for _, inter := range packet.Interfaces {
+		switch inter.(type) {
+		case *User, *raven.User:
+			return true
+		}
+	}
+	return false
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package logrus_sentry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeClone(t *testing.T) {
+	a := assert.New(t)
+
+	scope := NewScope()
+	scope.SetTag("region", "eu")
+	scope.SetExtra("order", 13)
+	scope.SetUser(User{User: raven.User{ID: "1"}})
+	scope.AddBreadcrumb(Breadcrumb{Message: "first"}, 2)
+
+	clone := scope.Clone()
+	clone.SetTag("region", "us")
+	clone.SetExtra("order", 14)
+	clone.SetUser(User{User: raven.User{ID: "2"}})
+	clone.AddBreadcrumb(Breadcrumb{Message: "second"}, 2)
+	clone.AddBreadcrumb(Breadcrumb{Message: "third"}, 2)
+
+	a.Equal("eu", scope.tags["region"])
+	a.Equal(13, scope.extra["order"])
+	a.Equal("1", scope.user.ID)
+	a.Len(scope.breadcrumbs.snapshot(), 1)
+
+	crumbs := clone.breadcrumbs.snapshot()
+	if a.Len(crumbs, 2) {
+		a.Equal("second", crumbs[0].Message)
+		a.Equal("third", crumbs[1].Message)
+	}
+
+	clone.ClearBreadcrumbs()
+	a.Empty(clone.breadcrumbs.snapshot())
+}
+
+func TestScopeContext(t *testing.T) {
+	a := assert.New(t)
+
+	_, ok := ScopeFromContext(context.Background())
+	a.False(ok)
+
+	scope := NewScope()
+	scope.SetTag("region", "eu")
+	scope.AddBreadcrumb(Breadcrumb{Message: "parent"}, 10)
+	ctx := WithScope(context.Background(), scope)
+	s, ok := ScopeFromContext(ctx)
+	a.True(ok)
+	a.Equal(scope, s)
+
+	// a breadcrumb context copies the scope without breadcrumbs
+	child, _ := ScopeFromContext(NewBreadcrumbContext(ctx))
+	a.Equal("eu", child.tags["region"])
+	a.Empty(child.breadcrumbs.snapshot())
+}
+
+func TestScopeEvent(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		logger.Hooks.Add(hook)
+
+		scope := NewScope()
+		scope.SetUser(User{User: raven.User{ID: "13"}})
+		scope.SetTag("region", "eu")
+		scope.SetTag("tenant", "acme")
+		scope.SetExtra("order", 13)
+		scope.SetExtra("cart", "scope")
+		scope.SetContext("app", map[string]interface{}{"version": "1.0"})
+		scope.SetFingerprint([]string{"scope"})
+		scope.SetLevel(raven.WARNING)
+		scope.AddBreadcrumb(Breadcrumb{Message: "scope breadcrumb"}, 10)
+		ctx := WithScope(context.Background(), scope)
+
+		logger.WithContext(ctx).WithFields(logrus.Fields{
+			"tags": raven.Tags{{Key: "tenant", Value: "entry"}},
+			"cart": "entry",
+		}).Error("failed")
+
+		packet := <-pch
+		assert.Equal(t, raven.WARNING, packet.Level)
+		assert.Equal(t, []string{"scope"}, packet.Fingerprint)
+		assert.Equal(t, raven.Tags{
+			{Key: "tenant", Value: "entry"},
+			{Key: "region", Value: "eu"},
+		}, packet.Tags)
+		assert.Equal(t, float64(13), packet.Extra["order"])
+		assert.Equal(t, "entry", packet.Extra["cart"])
+		if assert.Len(t, packet.Breadcrumbs.Values, 1) {
+			assert.Equal(t, "scope breadcrumb", packet.Breadcrumbs.Values[0].Message)
+		}
+	})
+}
+
+func TestScopeApply(t *testing.T) {
+	a := assert.New(t)
+
+	scope := NewScope()
+	scope.SetUser(User{User: raven.User{ID: "scope"}})
+	scope.SetContext("app", "scope")
+	scope.SetContext("os", "linux")
+
+	user := &User{User: raven.User{ID: "entry"}}
+	packet := raven.NewPacket(message, user, Contexts{"app": "entry"})
+	scope.apply(&Event{Packet: packet, hook: &SentryHook{}})
+
+	a.Len(packet.Interfaces, 2)
+	a.Equal(user, packet.Interfaces[0])
+	a.Equal(Contexts{"app": "entry", "os": "linux"}, packet.Interfaces[1])
+}
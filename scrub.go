@@ -0,0 +1,395 @@
+package logrus_sentry
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/getsentry/raven-go"
+)
+
+const markerFiltered = "[Filtered]"
+
+// ScrubStrategy is the way to replace sensitive data.
+type ScrubStrategy int
+
+const (
+	// ScrubMask replaces sensitive data with "[Filtered]".
+	ScrubMask ScrubStrategy = iota
+	// ScrubHash replaces sensitive data with its hash, so the same values
+	// can still be correlated.
+	ScrubHash
+	// ScrubRemove removes sensitive fields and values.
+	ScrubRemove
+)
+
+// ScrubPattern is a pattern of sensitive values.
+type ScrubPattern struct {
+	Regexp *regexp.Regexp
+	// Validate reports whether the matched string is actually sensitive.
+	// All matches are sensitive when it's nil.
+	Validate func(string) bool
+}
+
+// built-in patterns of sensitive values
+var (
+	EmailPattern = ScrubPattern{
+		Regexp: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+	CardNumberPattern = ScrubPattern{
+		Regexp:   regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		Validate: isLuhnValid,
+	}
+	BearerTokenPattern = ScrubPattern{
+		Regexp: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	}
+)
+
+var defaultScrubKeys = []string{
+	"password",
+	"passwd",
+	"passphrase",
+	"secret",
+	"token",
+	"authorization",
+	"api_key",
+	"access_key",
+	"private_key",
+	"credential",
+	"session_id",
+}
+
+var defaultScrubPatterns = []ScrubPattern{
+	EmailPattern,
+	CardNumberPattern,
+	BearerTokenPattern,
+}
+
+// ScrubConfiguration allows for configuring the scrubbing of sensitive data.
+// It is applied to the message, extra, tags, user, http request and
+// breadcrumbs of events.
+type ScrubConfiguration struct {
+	// whether sensitive data should be scrubbed
+	Enable bool
+	// the keys of sensitive fields. keys are matched as case-insensitive
+	// substrings, ignoring "-" and "_", so "api_key" matches "X-Api-Key".
+	Keys []string
+	// the patterns of sensitive values
+	Patterns []ScrubPattern
+	// the way to replace sensitive data
+	Strategy ScrubStrategy
+	// the key of HMAC used by ScrubHash. a random key of the process is used
+	// when it's empty, so hashes are not kept across restarts
+	HashKey []byte
+}
+
+func (conf *ScrubConfiguration) scrubPacket(packet *raven.Packet) {
+	packet.Message = conf.scrubString(packet.Message)
+	packet.Extra = conf.scrubMap(packet.Extra)
+	packet.Tags = conf.scrubTags(packet.Tags)
+
+	for i, inter := range packet.Interfaces {
+		switch v := inter.(type) {
+		case *User:
+			packet.Interfaces[i] = conf.scrubUser(v)
+		case *raven.Http:
+			packet.Interfaces[i] = conf.scrubHTTP(v)
+		case *Breadcrumbs:
+			packet.Interfaces[i] = conf.scrubBreadcrumbs(v)
+		case Spans:
+			packet.Interfaces[i] = conf.scrubSpans(v)
+		}
+	}
+}
+
+func (conf *ScrubConfiguration) isSensitiveKey(key string) bool {
+	key = normalizeScrubKey(key)
+	for _, k := range conf.Keys {
+		if k = normalizeScrubKey(k); k != "" && strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeScrubKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.Replace(key, "-", "", -1)
+	return strings.Replace(key, "_", "", -1)
+}
+
+// replace returns the replacement of a whole sensitive value.
+func (conf *ScrubConfiguration) replace(value string) string {
+	switch conf.Strategy {
+	case ScrubHash:
+		return conf.hash(value)
+	case ScrubRemove:
+		return ""
+	default:
+		return markerFiltered
+	}
+}
+
+func (conf *ScrubConfiguration) hash(value string) string {
+	return hashValue(conf.HashKey, value)
+}
+
+// processHashKey is the key of HMAC used when no key is configured. It's
+// random per process, so the hashes can't be reversed with a dictionary, but
+// they correlate the same values only within the process.
+var (
+	processHashKeyOnce sync.Once
+	processHashKey     []byte
+)
+
+func defaultHashKey() []byte {
+	processHashKeyOnce.Do(func() {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err == nil {
+			processHashKey = key
+		}
+	})
+	return processHashKey
+}
+
+// hashValue returns the HMAC-SHA256 of value. The key of the process is used
+// when key is empty, and the value is filtered when it can't be created.
+func hashValue(key []byte, value string) string {
+	if len(key) == 0 {
+		if key = defaultHashKey(); key == nil {
+			return markerFiltered
+		}
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return "[hash:" + hex.EncodeToString(mac.Sum(nil)[:8]) + "]"
+}
+
+// scrubString replaces the sensitive parts of s matched by the patterns.
+func (conf *ScrubConfiguration) scrubString(s string) string {
+	for _, p := range conf.Patterns {
+		if p.Regexp == nil {
+			continue
+		}
+		s = p.Regexp.ReplaceAllStringFunc(s, func(match string) string {
+			if p.Validate != nil && !p.Validate(match) {
+				return match
+			}
+			return conf.replace(match)
+		})
+	}
+	return s
+}
+
+// scrubValue scrubs the strings in the value. Maps and slices created by the
+// normalizer are walked, other values are returned as they are.
+func (conf *ScrubConfiguration) scrubValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return conf.scrubString(v)
+	case map[string]interface{}:
+		return conf.scrubMap(v)
+	case raven.Extra:
+		return conf.scrubMap(v)
+	case map[string]string:
+		return conf.scrubStringMap(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i := range v {
+			result[i] = conf.scrubValue(v[i])
+		}
+		return result
+	case []string:
+		result := make([]string, len(v))
+		for i := range v {
+			result[i] = conf.scrubString(v[i])
+		}
+		return result
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		// structs and typed maps and slices, which are not normalized when
+		// normalization is disabled, are walked as they are sent
+		switch normalized := (&NormalizeConfiguration{}).normalize(value).(type) {
+		case map[string]interface{}, []interface{}, string:
+			return conf.scrubValue(normalized)
+		}
+	}
+	return value
+}
+
+// scrubMap returns a scrubbed copy of m.
+func (conf *ScrubConfiguration) scrubMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if !conf.isSensitiveKey(k) {
+			result[k] = conf.scrubValue(v)
+			continue
+		}
+		if conf.Strategy == ScrubRemove {
+			continue
+		}
+		result[k] = conf.replace(fmt.Sprint(v))
+	}
+	return result
+}
+
+// scrubStringMap returns a scrubbed copy of m.
+func (conf *ScrubConfiguration) scrubStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		if !conf.isSensitiveKey(k) {
+			result[k] = conf.scrubString(v)
+			continue
+		}
+		if conf.Strategy == ScrubRemove {
+			continue
+		}
+		result[k] = conf.replace(v)
+	}
+	return result
+}
+
+func (conf *ScrubConfiguration) scrubTags(tags raven.Tags) raven.Tags {
+	if tags == nil {
+		return nil
+	}
+	result := make(raven.Tags, 0, len(tags))
+	for _, tag := range tags {
+		if !conf.isSensitiveKey(tag.Key) {
+			tag.Value = conf.scrubString(tag.Value)
+		} else if conf.Strategy == ScrubRemove {
+			continue
+		} else {
+			tag.Value = conf.replace(tag.Value)
+		}
+		result = append(result, tag)
+	}
+	return result
+}
+
+// scrubUser returns a scrubbed copy of user.
+func (conf *ScrubConfiguration) scrubUser(user *User) *User {
+	u := *user
+	u.ID = conf.scrubString(u.ID)
+	u.Username = conf.scrubString(u.Username)
+	u.Email = conf.scrubString(u.Email)
+	u.Data = conf.scrubMap(u.Data)
+	return &u
+}
+
+// scrubHTTP returns a scrubbed copy of h.
+func (conf *ScrubConfiguration) scrubHTTP(h *raven.Http) *raven.Http {
+	req := *h
+	req.URL = conf.scrubString(req.URL)
+	req.Query = conf.scrubQuery(req.Query)
+	req.Cookies = conf.scrubCookies(req.Cookies)
+	req.Headers = conf.scrubStringMap(req.Headers)
+	req.Data = conf.scrubValue(req.Data)
+	return &req
+}
+
+// scrubBreadcrumbs returns a scrubbed copy of crumbs.
+func (conf *ScrubConfiguration) scrubBreadcrumbs(crumbs *Breadcrumbs) *Breadcrumbs {
+	result := &Breadcrumbs{Values: make([]Breadcrumb, len(crumbs.Values))}
+	for i, v := range crumbs.Values {
+		v.Message = conf.scrubString(v.Message)
+		v.Data = conf.scrubMap(v.Data)
+		result.Values[i] = v
+	}
+	return result
+}
+
+func (conf *ScrubConfiguration) scrubSpans(spans Spans) Spans {
+	result := spans.mapDescriptions(conf.scrubString)
+	for _, span := range result {
+		if data, ok := span["data"].(map[string]interface{}); ok {
+			span["data"] = conf.scrubMap(data)
+		}
+		if tags, ok := span["tags"].(map[string]string); ok {
+			scrubbed := make(map[string]string, len(tags))
+			t, _ := toTags(tags)
+			for _, tag := range conf.scrubTags(t) {
+				scrubbed[tag.Key] = tag.Value
+			}
+			span["tags"] = scrubbed
+		}
+	}
+	return result
+}
+
+func (conf *ScrubConfiguration) scrubQuery(query string) string {
+	if query == "" {
+		return query
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return conf.scrubString(query)
+	}
+	for k, vs := range values {
+		if conf.isSensitiveKey(k) {
+			if conf.Strategy == ScrubRemove {
+				delete(values, k)
+				continue
+			}
+			for i := range vs {
+				vs[i] = conf.replace(vs[i])
+			}
+			continue
+		}
+		for i := range vs {
+			vs[i] = conf.scrubString(vs[i])
+		}
+	}
+	return values.Encode()
+}
+
+func (conf *ScrubConfiguration) scrubCookies(cookies string) string {
+	return mapCookies(cookies, func(name, value string) (string, bool) {
+		if !conf.isSensitiveKey(name) {
+			return conf.scrubString(value), true
+		}
+		if conf.Strategy == ScrubRemove {
+			return "", false
+		}
+		return conf.replace(value), true
+	})
+}
+
+// isLuhnValid reports whether the digits in s pass the Luhn checksum,
+// which all the card numbers do.
+func isLuhnValid(s string) bool {
+	var sum, digits int
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,219 @@
+package logrus_sentry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestScrubConfiguration(strategy ScrubStrategy) *ScrubConfiguration {
+	return &ScrubConfiguration{
+		Enable:   true,
+		Keys:     defaultScrubKeys,
+		Patterns: defaultScrubPatterns,
+		Strategy: strategy,
+	}
+}
+
+func TestScrubString(t *testing.T) {
+	a := assert.New(t)
+
+	conf := newTestScrubConfiguration(ScrubMask)
+	removeConf := newTestScrubConfiguration(ScrubRemove)
+
+	tests := []struct {
+		conf        *ScrubConfiguration
+		value       string
+		expected    string
+		description string
+	}{
+		{conf, "no sensitive data", "no sensitive data", "plain"},
+		{conf, "mail to foo.bar+baz@example.com failed", "mail to [Filtered] failed", "email"},
+		{conf, "card 4111 1111 1111 1111 declined", "card [Filtered] declined", "card number"},
+		{conf, "card 4111-1111-1111-1111", "card [Filtered]", "card number with dashes"},
+		{conf, "timestamp 1514764800000", "timestamp 1514764800000", "not a card number"},
+		{conf, "header Bearer abc.def-ghi", "header [Filtered]", "bearer token"},
+		{removeConf, "mail to foo@example.com failed", "mail to  failed", "remove email"},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+		a.Equal(tt.expected, tt.conf.scrubString(tt.value), target)
+	}
+}
+
+func TestScrubIsSensitiveKey(t *testing.T) {
+	a := assert.New(t)
+
+	conf := newTestScrubConfiguration(ScrubMask)
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{"password", true},
+		{"user_password", true},
+		{"Authorization", true},
+		{"X-Api-Key", true},
+		{"apikey", true},
+		{"SessionID", true},
+		{"name", false},
+		{"author", false},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+		a.Equal(tt.expected, conf.isSensitiveKey(tt.key), target)
+	}
+}
+
+func TestScrubStrategy(t *testing.T) {
+	a := assert.New(t)
+
+	data := map[string]interface{}{
+		"password": "hunter2",
+		"nested": map[string]interface{}{
+			"token": 12345,
+			"list":  []interface{}{"foo@example.com", 1},
+		},
+		"order": 13,
+	}
+
+	result := newTestScrubConfiguration(ScrubMask).scrubMap(data)
+	a.Equal(map[string]interface{}{
+		"password": markerFiltered,
+		"nested": map[string]interface{}{
+			"token": markerFiltered,
+			"list":  []interface{}{markerFiltered, 1},
+		},
+		"order": 13,
+	}, result)
+	a.Equal("hunter2", data["password"], "original data should not be modified")
+
+	result = newTestScrubConfiguration(ScrubRemove).scrubMap(data)
+	a.Equal(map[string]interface{}{
+		"nested": map[string]interface{}{
+			"list": []interface{}{"", 1},
+		},
+		"order": 13,
+	}, result)
+
+	hashConf := newTestScrubConfiguration(ScrubHash)
+	result = hashConf.scrubMap(data)
+	a.Equal(hashConf.hash("hunter2"), result["password"])
+	a.Regexp(`^\[hash:[0-9a-f]{16}\]$`, result["password"])
+
+	hashConf.HashKey = []byte("key")
+	a.NotEqual(result["password"], hashConf.scrubMap(data)["password"], "HashKey should change the hash")
+}
+
+func TestScrubPacket(t *testing.T) {
+	a := assert.New(t)
+
+	req, _ := http.NewRequest("GET", "http://example.com/path?token=abc&q=foo@example.com", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("Cookie", "session_id=abc; theme=dark")
+	ravenReq := raven.NewHttp(req)
+
+	packet := raven.NewPacket("login failed for foo@example.com", ravenReq, &User{
+		User: raven.User{ID: "1", Email: "foo@example.com"},
+		Data: map[string]interface{}{"api_key": "abc"},
+	})
+	packet.Tags = raven.Tags{{Key: "token", Value: "abc"}, {Key: "site", Value: "example.com"}}
+
+	newTestScrubConfiguration(ScrubMask).scrubPacket(packet)
+	a.Equal("login failed for [Filtered]", packet.Message)
+	a.Equal(raven.Tags{{Key: "token", Value: markerFiltered}, {Key: "site", Value: "example.com"}}, packet.Tags)
+
+	h := packet.Interfaces[0].(*raven.Http)
+	a.Equal("q=%5BFiltered%5D&token=%5BFiltered%5D", h.Query)
+	a.Equal(markerFiltered, h.Headers["Authorization"])
+	a.Equal("session_id=[Filtered]; theme=dark", h.Cookies)
+	a.Equal("Bearer abc", ravenReq.Headers["Authorization"], "original http interface should not be modified")
+
+	u := packet.Interfaces[1].(*User)
+	a.Equal("1", u.ID)
+	a.Equal(markerFiltered, u.Email)
+	a.Equal(map[string]interface{}{"api_key": markerFiltered}, u.Data)
+}
+
+func TestScrubConfiguration(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.ScrubConfiguration.Enable = true
+		logger.Hooks.Add(hook)
+
+		logger.WithFields(logrus.Fields{
+			"password": "hunter2",
+			"email":    "foo@example.com",
+		}).Error("failed for foo@example.com")
+
+		packet := <-pch
+		if packet.Message != "failed for [Filtered]" {
+			t.Errorf("message should be scrubbed, was %s", packet.Message)
+		}
+		if packet.Extra["password"] != markerFiltered {
+			t.Errorf("password should be scrubbed, was %v", packet.Extra["password"])
+		}
+		if packet.Extra["email"] != markerFiltered {
+			t.Errorf("email should be scrubbed, was %v", packet.Extra["email"])
+		}
+	})
+}
+
+func TestHashValueWithoutKey(t *testing.T) {
+	a := assert.New(t)
+
+	sum := sha256.Sum256([]byte("hunter2"))
+	hashed := hashValue(nil, "hunter2")
+	a.Regexp(`^\[hash:[0-9a-f]{16}\]$`, hashed)
+	a.NotEqual("[hash:"+hex.EncodeToString(sum[:8])+"]", hashed, "unkeyed SHA-256 should not be used")
+	a.Equal(hashed, hashValue(nil, "hunter2"), "the key should be kept within the process")
+	a.Equal(hashValue(defaultHashKey(), "hunter2"), hashed)
+}
+
+type scrubTestCredentials struct {
+	User     string
+	Password string `json:"password"`
+}
+
+func TestScrubWithoutNormalization(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.ScrubConfiguration.Enable = true
+		hook.NormalizeConfiguration.Enable = false
+		logger.Hooks.Add(hook)
+
+		logger.WithFields(logrus.Fields{
+			"login":    scrubTestCredentials{User: "foo", Password: "hunter2"},
+			"headers":  map[string][]string{"Authorization": {"Bearer abc"}},
+			"emails":   []*string{nil},
+			"contacts": [1]string{"foo@example.com"},
+		}).Error("failed")
+
+		packet := <-pch
+		assert.Equal(t, map[string]interface{}{
+			"User":     "foo",
+			"password": markerFiltered,
+		}, packet.Extra["login"])
+		assert.Equal(t, map[string]interface{}{"Authorization": markerFiltered}, packet.Extra["headers"])
+		assert.Equal(t, []interface{}{markerFiltered}, packet.Extra["contacts"])
+	})
+}
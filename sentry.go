@@ -1,8 +1,11 @@
 package logrus_sentry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"reflect"
 	"runtime"
 	"sync"
 	"time"
@@ -35,16 +38,53 @@ type SentryHook struct {
 	// you probably want to create your own raven.Client and set
 	// ravenClient.Transport.(*raven.HTTPTransport).Client.Timeout to set a
 	// timeout on the underlying HTTP request instead.
-	Timeout                 time.Duration
-	StacktraceConfiguration StackTraceConfiguration
+	Timeout                  time.Duration
+	StacktraceConfiguration  StackTraceConfiguration
+	HTTPRequestConfiguration HTTPRequestConfiguration
+	NormalizeConfiguration   NormalizeConfiguration
+	ScrubConfiguration       ScrubConfiguration
+	URLConfiguration         URLConfiguration
+	SecretConfiguration      SecretConfiguration
+	BreadcrumbConfiguration  BreadcrumbConfiguration
+	SQLConfiguration         SQLConfiguration
+	TraceConfiguration       TraceConfiguration
+	TracingConfiguration     TracingConfiguration
+	PrivacyConfiguration     PrivacyConfiguration
 
 	client *raven.Client
 	levels []logrus.Level
 
 	serverName    string
 	ignoreFields  map[string]struct{}
+	fields        fieldSelector
 	extraFilters  map[string]func(interface{}) interface{}
+	typeFilters   map[reflect.Type]func(interface{}) interface{}
+	ifaceFilters  []interfaceFilter
 	errorHandlers []func(entry *logrus.Entry, err error)
+	beforeSend    []func(entry *logrus.Entry, packet *raven.Packet) *raven.Packet
+	stages        []eventStage
+	stats         *stats
+	breadcrumbs   breadcrumbBuffer
+
+	// functions extracting fields from the context of entries
+	contextExtractors []func(ctx context.Context) map[string]interface{}
+
+	// whether the other user_* fields are sent as attributes of the user
+	userAttributePrefix bool
+
+	// the tags, extra data and contexts merged into events
+	defaults *Scope
+
+	// the public key of the DSN and the environment for the dynamic sampling
+	// context, which raven.Client doesn't expose
+	dscMu       sync.RWMutex
+	publicKey   string
+	environment string
+
+	// the hook derived from, and the defaults overridden by With
+	parent            *SentryHook
+	loggerName        string
+	fingerprintPrefix []string
 
 	asynchronous bool
 
@@ -95,7 +135,11 @@ func NewSentryHook(DSN string, levels []logrus.Level) (*SentryHook, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewWithClientSentryHook(client, levels)
+	hook, err := NewWithClientSentryHook(client, levels)
+	if DSN != "" {
+		hook.publicKey = publicKeyOfDSN(DSN)
+	}
+	return hook, err
 }
 
 // NewWithTagsSentryHook creates a hook with tags to be added to an instance
@@ -106,7 +150,11 @@ func NewWithTagsSentryHook(DSN string, tags map[string]string, levels []logrus.L
 	if err != nil {
 		return nil, err
 	}
-	return NewWithClientSentryHook(client, levels)
+	hook, err := NewWithClientSentryHook(client, levels)
+	if DSN != "" {
+		hook.publicKey = publicKeyOfDSN(DSN)
+	}
+	return hook, err
 }
 
 // NewWithClientSentryHook creates a hook using an initialized raven client.
@@ -122,10 +170,63 @@ func NewWithClientSentryHook(client *raven.Client, levels []logrus.Level) (*Sent
 			InAppPrefixes:     nil,
 			SendExceptionType: true,
 		},
+		HTTPRequestConfiguration: HTTPRequestConfiguration{
+			IncludeBody:  false,
+			MaxBodySize:  10 * 1024,
+			ContentTypes: defaultBodyContentTypes,
+
+			SensitiveHeaders: defaultSensitiveHeaders,
+			SensitiveCookies: defaultSensitiveCookies,
+		},
+		NormalizeConfiguration: NormalizeConfiguration{
+			Enable:          true,
+			MaxDepth:        8,
+			MaxBreadth:      100,
+			MaxStringLength: 8192,
+		},
+		ScrubConfiguration: ScrubConfiguration{
+			Enable:   false,
+			Keys:     defaultScrubKeys,
+			Patterns: defaultScrubPatterns,
+			Strategy: ScrubMask,
+		},
+		URLConfiguration: URLConfiguration{
+			Enable:          true,
+			SensitiveParams: defaultSensitiveParams,
+		},
+		SecretConfiguration: SecretConfiguration{
+			Enable: true,
+			// copied so patterns can be disabled per hook
+			Patterns: append([]SecretPattern(nil), defaultSecretPatterns...),
+		},
+		BreadcrumbConfiguration: BreadcrumbConfiguration{
+			Enable:         false,
+			Levels:         []logrus.Level{logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel},
+			MaxBreadcrumbs: 100,
+		},
+		SQLConfiguration: SQLConfiguration{
+			IncludeParams: false,
+			RedactParams:  true,
+		},
+		TraceConfiguration: TraceConfiguration{
+			Enable: true,
+			Tags:   true,
+		},
+		TracingConfiguration: TracingConfiguration{
+			SampleRate: 1.0,
+			MaxSpans:   1000,
+		},
 		client:       client,
 		levels:       levels,
 		ignoreFields: make(map[string]struct{}),
 		extraFilters: make(map[string]func(interface{}) interface{}),
+		typeFilters:  make(map[reflect.Type]func(interface{}) interface{}),
+		stages:       defaultEventStages(),
+		stats:        &stats{},
+		defaults:     NewScope(),
+		// raven.Client reads them from the same variables
+		publicKey:   publicKeyOfDSN(os.Getenv("SENTRY_DSN")),
+		environment: os.Getenv("SENTRY_ENVIRONMENT"),
 	}, nil
 }
 
@@ -161,117 +262,78 @@ func setAsync(hook *SentryHook) *SentryHook {
 // Fire is called when an event should be sent to sentry
 // Special fields that sentry uses to give more information to the server
 // are extracted from entry.Data (if they are found)
-// These fields are: error, logger, server_name, http_request, tags
+// These fields are: error, logger, server_name, http_request, http_request_body, tags
+// The event is built by the event processor pipeline, see AddEventProcessor.
 func (hook *SentryHook) Fire(entry *logrus.Entry) error {
-	hook.mu.RLock() // Allow multiple go routines to log simultaneously
-	defer hook.mu.RUnlock()
-
-	df := newDataField(entry.Data)
-
-	err, hasError := df.getError()
-	var crumbs *Breadcrumbs
-	if hasError && hook.StacktraceConfiguration.IncludeErrorBreadcrumb {
-		crumbs = &Breadcrumbs{
-			Values: []Value{{
-				Timestamp: int64(time.Now().Unix()),
-				Type:      "error",
-				Message:   fmt.Sprintf("%+v", err),
-			}},
-		}
+	root := hook.root()
+	root.mu.RLock() // Allow multiple go routines to log simultaneously
+	defer root.mu.RUnlock()
+
+	if hook.BreadcrumbConfiguration.Enable && !hook.isEventLevel(entry.Level) {
+		hook.recordBreadcrumb(entry)
+		return nil
 	}
 
-	packet := raven.NewPacketWithExtra(entry.Message, nil, crumbs)
+	packet := raven.NewPacketWithExtra(entry.Message, nil)
 	packet.Timestamp = raven.Timestamp(entry.Time)
 	packet.Level = severityMap[entry.Level]
 	packet.Platform = "go"
 
-	// set special fields
-	if hook.serverName != "" {
-		packet.ServerName = hook.serverName
-	}
-	if logger, ok := df.getLogger(); ok {
-		packet.Logger = logger
-	}
-	if serverName, ok := df.getServerName(); ok {
-		packet.ServerName = serverName
-	}
-	if eventID, ok := df.getEventID(); ok {
-		packet.EventID = eventID
-	}
-	if tags, ok := df.getTags(); ok {
-		packet.Tags = tags
-	}
-	if fingerprint, ok := df.getFingerprint(); ok {
-		packet.Fingerprint = fingerprint
-	}
-	if req, ok := df.getHTTPRequest(); ok {
-		packet.Interfaces = append(packet.Interfaces, req)
-	}
-	if user, ok := df.getUser(); ok {
-		packet.Interfaces = append(packet.Interfaces, user)
+	ev := &Event{
+		Entry:  entry,
+		Packet: packet,
+		hook:   hook,
+		df:     newDataField(hook.extractContext(entry)),
 	}
 
-	// set stacktrace data
+	// the stacktrace is taken here, so that Skip counts the frames from Fire
+	// regardless of the stages of the pipeline
 	stConfig := &hook.StacktraceConfiguration
 	if stConfig.Enable && entry.Level <= stConfig.Level {
-		if err, ok := df.getError(); ok {
-			var currentStacktrace *raven.Stacktrace
-			currentStacktrace = hook.findStacktrace(err)
-			if currentStacktrace == nil {
-				currentStacktrace = raven.NewStacktrace(stConfig.Skip, stConfig.Context, stConfig.InAppPrefixes)
-			}
-			cause := errors.Cause(err)
-			if cause == nil {
-				cause = err
-			}
-			exc := raven.NewException(cause, currentStacktrace)
-			if !stConfig.SendExceptionType {
-				exc.Type = ""
-			}
-			if stConfig.SwitchExceptionTypeAndMessage {
-				packet.Interfaces = append(packet.Interfaces, currentStacktrace)
-				packet.Culprit = exc.Type + ": " + currentStacktrace.Culprit()
-			} else {
-				packet.Interfaces = append(packet.Interfaces, exc)
-				packet.Culprit = err.Error()
-			}
-		} else {
-			currentStacktrace := raven.NewStacktrace(stConfig.Skip, stConfig.Context, stConfig.InAppPrefixes)
-			if currentStacktrace != nil {
-				packet.Interfaces = append(packet.Interfaces, currentStacktrace)
-			}
-		}
-	} else {
-		// set the culprit even when the stack trace is disabled, as long as we have an error
-		if err, ok := df.getError(); ok {
-			packet.Culprit = err.Error()
-		}
+		ev.stacktrace = raven.NewStacktrace(stConfig.Skip, stConfig.Context, stConfig.InAppPrefixes)
 	}
 
-	// set other fields
-	dataExtra := hook.formatExtraData(df)
-	if packet.Extra == nil {
-		packet.Extra = dataExtra
-	} else {
-		for k, v := range dataExtra {
-			packet.Extra[k] = v
+	return hook.send(ev)
+}
+
+// send processes the event by the pipeline and the BeforeSend callbacks, and
+// captures it unless it's dropped. hook.root().mu must be read-locked.
+func (hook *SentryHook) send(ev *Event) error {
+	entry := ev.Entry
+	if ev = hook.processEvent(ev); ev == nil {
+		hook.stats.addDropped()
+		return nil
+	}
+	packet := ev.Packet
+
+	for _, fn := range hook.beforeSend {
+		if packet = fn(entry, packet); packet == nil {
+			hook.stats.addDropped()
+			return nil
 		}
 	}
 
+	return hook.capture(entry, packet)
+}
+
+// capture sends the packet, and waits for the result unless the hook is
+// asynchronous. hook.root().mu must be read-locked.
+func (hook *SentryHook) capture(entry *logrus.Entry, packet *raven.Packet) error {
 	_, errCh := hook.client.Capture(packet, nil)
 
 	switch {
 	case hook.asynchronous:
 		// Our use of hook.mu guarantees that we are following the WaitGroup rule of
 		// not calling Add in parallel with Wait.
-		hook.wg.Add(1)
+		wg := &hook.root().wg
+		wg.Add(1)
 		go func() {
 			if err := <-errCh; err != nil {
 				for _, handlerFn := range hook.errorHandlers {
 					handlerFn(entry, err)
 				}
 			}
-			hook.wg.Done()
+			wg.Done()
 		}()
 		return nil
 	case hook.Timeout == 0:
@@ -291,16 +353,180 @@ func (hook *SentryHook) Fire(entry *logrus.Entry) error {
 	}
 }
 
+// processFields sets the special fields of the packet.
+func processFields(ev *Event) *Event {
+	hook, df, packet := ev.hook, ev.df, ev.Packet
+	if hook.serverName != "" {
+		packet.ServerName = hook.serverName
+	}
+	if hook.loggerName != "" {
+		packet.Logger = hook.loggerName
+	}
+	if logger, ok := df.getLogger(); ok {
+		packet.Logger = logger
+	}
+	if serverName, ok := df.getServerName(); ok {
+		packet.ServerName = serverName
+	}
+	if eventID, ok := df.getEventID(); ok {
+		packet.EventID = eventID
+	}
+	if fingerprint, ok := df.getFingerprint(); ok {
+		packet.Fingerprint = fingerprint
+	}
+	return ev
+}
+
+func processTags(ev *Event) *Event {
+	if tags, ok := ev.df.getTags(); ok {
+		ev.Packet.Tags = tags
+	}
+	return ev
+}
+
+func processHTTP(ev *Event) *Event {
+	if req, ok := ev.hook.getHTTPRequest(ev.df); ok {
+		ev.Packet.Interfaces = append(ev.Packet.Interfaces, req)
+	}
+	return ev
+}
+
+func processUser(ev *Event) *Event {
+	if user, ok := ev.df.getUser(ev.hook.userAttributePrefix); ok {
+		ev.Packet.Interfaces = append(ev.Packet.Interfaces, user)
+	}
+	return ev
+}
+
+// processStacktrace sets the exception, stacktrace and culprit of the packet.
+func processStacktrace(ev *Event) *Event {
+	hook, packet := ev.hook, ev.Packet
+	err, hasError := ev.df.getError()
+
+	stConfig := &hook.StacktraceConfiguration
+	if !stConfig.Enable || ev.Entry.Level > stConfig.Level {
+		// set the culprit even when the stack trace is disabled, as long as we have an error
+		if hasError {
+			packet.Culprit = err.Error()
+		}
+		return ev
+	}
+
+	if !hasError {
+		if ev.stacktrace != nil {
+			packet.Interfaces = append(packet.Interfaces, ev.stacktrace)
+		}
+		return ev
+	}
+
+	currentStacktrace := hook.findStacktrace(err)
+	if currentStacktrace == nil {
+		currentStacktrace = ev.stacktrace
+	}
+	cause := errors.Cause(err)
+	if cause == nil {
+		cause = err
+	}
+	exc := raven.NewException(cause, currentStacktrace)
+	if !stConfig.SendExceptionType {
+		exc.Type = ""
+	}
+	if stConfig.SwitchExceptionTypeAndMessage {
+		packet.Interfaces = append(packet.Interfaces, currentStacktrace)
+		packet.Culprit = exc.Type + ": " + currentStacktrace.Culprit()
+	} else {
+		packet.Interfaces = append(packet.Interfaces, exc)
+		packet.Culprit = err.Error()
+	}
+	return ev
+}
+
+func processBreadcrumbs(ev *Event) *Event {
+	if ev.transaction {
+		return ev
+	}
+	var values []Breadcrumb
+	if b, ok := breadcrumbBufferFromContext(ev.Entry.Context); ok {
+		// breadcrumbs can be added to scopes without enabling them
+		values = b.snapshot()
+	} else if ev.hook.BreadcrumbConfiguration.Enable {
+		values = ev.hook.root().breadcrumbs.snapshot()
+	}
+	if crumbs, ok := ev.df.getBreadcrumbs(); ok {
+		values = append(values, withTimestamp(crumbs, ev.Entry.Time)...)
+	}
+
+	err, hasError := ev.df.getError()
+	if hasError && ev.hook.StacktraceConfiguration.IncludeErrorBreadcrumb {
+		values = append(values, Breadcrumb{
+			Timestamp: ev.Entry.Time,
+			Type:      "error",
+			Message:   fmt.Sprintf("%+v", err),
+			Level:     raven.ERROR,
+		})
+	}
+	// keep the most recent ones when the field and the error exceed the limit
+	if max := ev.hook.BreadcrumbConfiguration.MaxBreadcrumbs; max > 0 && len(values) > max {
+		values = values[len(values)-max:]
+	}
+	if len(values) != 0 {
+		ev.Packet.Interfaces = append(ev.Packet.Interfaces, &Breadcrumbs{Values: values})
+	}
+	return ev
+}
+
+// processExtra sets the fields which are not used by the other stages as
+// extra data.
+func processExtra(ev *Event) *Event {
+	packet := ev.Packet
+	dataExtra := ev.hook.formatExtraData(ev.df)
+	if packet.Extra == nil {
+		packet.Extra = dataExtra
+	} else {
+		for k, v := range dataExtra {
+			packet.Extra[k] = v
+		}
+	}
+	return ev
+}
+
+func processURL(ev *Event) *Event {
+	if conf := &ev.hook.URLConfiguration; conf.Enable {
+		conf.redactPacket(ev.Packet)
+	}
+	return ev
+}
+
+func processSecrets(ev *Event) *Event {
+	if conf := &ev.hook.SecretConfiguration; conf.Enable {
+		conf.redactPacket(ev.Packet)
+	}
+	return ev
+}
+
+func processPrivacy(ev *Event) *Event {
+	ev.hook.PrivacyConfiguration.anonymizePacket(ev.Packet)
+	return ev
+}
+
+func processScrub(ev *Event) *Event {
+	if conf := &ev.hook.ScrubConfiguration; conf.Enable {
+		conf.scrubPacket(ev.Packet)
+	}
+	return ev
+}
+
 // Flush waits for the log queue to empty. This function only does anything in
 // asynchronous mode.
 func (hook *SentryHook) Flush() {
 	if !hook.asynchronous {
 		return
 	}
-	hook.mu.Lock() // Claim exclusive access; any logging goroutines will block until the flush completes
-	defer hook.mu.Unlock()
+	root := hook.root()
+	root.mu.Lock() // Claim exclusive access; any logging goroutines will block until the flush completes
+	defer root.mu.Unlock()
 
-	hook.wg.Wait()
+	root.wg.Wait()
 }
 
 func (hook *SentryHook) findStacktrace(err error) *raven.Stacktrace {
@@ -351,8 +577,18 @@ func (hook *SentryHook) convertStackTrace(st errors.StackTrace) *raven.Stacktrac
 }
 
 // Levels returns the available logging levels.
+// The levels of breadcrumbs are included when they are enabled.
 func (hook *SentryHook) Levels() []logrus.Level {
-	return hook.levels
+	if !hook.BreadcrumbConfiguration.Enable {
+		return hook.levels
+	}
+	levels := append([]logrus.Level(nil), hook.levels...)
+	for _, l := range hook.BreadcrumbConfiguration.Levels {
+		if !hook.isEventLevel(l) {
+			levels = append(levels, l)
+		}
+	}
+	return levels
 }
 
 // AddIgnore adds field name to ignore.
@@ -360,11 +596,67 @@ func (hook *SentryHook) AddIgnore(name string) {
 	hook.ignoreFields[name] = struct{}{}
 }
 
+// SetUserAttributePrefix sets whether the fields prefixed with "user_" other
+// than the fields of the user are sent as additional attributes of the user
+// instead of extra data, e.g. "user_plan" is sent as "plan".
+func (hook *SentryHook) SetUserAttributePrefix(enable bool) {
+	hook.userAttributePrefix = enable
+}
+
+// AddIgnorePattern adds a pattern of fields to ignore.
+// "." separates the keys of nested maps and the fields of structs, and each
+// key is matched as path.Match, e.g. "auth_*" or "payload.card.number".
+func (hook *SentryHook) AddIgnorePattern(pattern string) {
+	hook.fields.ignore = append(hook.fields.ignore, newFieldPattern(pattern))
+}
+
+// AddAllowPattern adds a pattern of fields to send in allowlist mode.
+// Patterns are the same as AddIgnorePattern.
+func (hook *SentryHook) AddAllowPattern(pattern string) {
+	hook.fields.allow = append(hook.fields.allow, newFieldPattern(pattern))
+}
+
+// SetAllowlistMode sets whether only the fields matched by the allow patterns
+// are sent as extra data.
+func (hook *SentryHook) SetAllowlistMode(enable bool) {
+	hook.fields.allowlistOnly = enable
+}
+
 // AddExtraFilter adds a custom filter function.
 func (hook *SentryHook) AddExtraFilter(name string, fn func(interface{}) interface{}) {
 	hook.extraFilters[name] = fn
 }
 
+// AddBeforeSend adds a callback function called with the event right before
+// it is sent. The callback can modify the event, or return nil to drop it.
+// Callbacks are called in the order they were added, and the rest of them
+// are skipped once the event is dropped.
+func (hook *SentryHook) AddBeforeSend(fn func(entry *logrus.Entry, packet *raven.Packet) *raven.Packet) {
+	hook.beforeSend = append(hook.beforeSend, fn)
+}
+
+// Stats returns the statistics of the events handled by the hook.
+func (hook *SentryHook) Stats() Stats {
+	return hook.stats.snapshot()
+}
+
+// AddTypeFormatter adds a custom formatter function used for the fields whose
+// value has the same type as sample.
+// When sample is a nil pointer to an interface, e.g. (*fmt.Stringer)(nil),
+// the function is used for the values implementing the interface.
+// Filters added by AddExtraFilter take precedence over type formatters.
+func (hook *SentryHook) AddTypeFormatter(sample interface{}, fn func(interface{}) interface{}) {
+	typ := reflect.TypeOf(sample)
+	if typ == nil {
+		return
+	}
+	if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Interface {
+		hook.ifaceFilters = append(hook.ifaceFilters, interfaceFilter{typ.Elem(), fn})
+		return
+	}
+	hook.typeFilters[typ] = fn
+}
+
 // AddErrorHandler adds a error handler function used when Sentry returns error.
 func (hook *SentryHook) AddErrorHandler(fn func(entry *logrus.Entry, err error)) {
 	hook.errorHandlers = append(hook.errorHandlers, fn)
@@ -373,6 +665,7 @@ func (hook *SentryHook) AddErrorHandler(fn func(entry *logrus.Entry, err error))
 func (hook *SentryHook) formatExtraData(df *dataField) (result map[string]interface{}) {
 	// create a map for passing to Sentry's extra data
 	result = make(map[string]interface{}, df.len())
+	rules := hook.fields.rules()
 	for k, v := range df.data {
 		if df.isOmit(k) {
 			continue // skip already used special fields
@@ -380,17 +673,60 @@ func (hook *SentryHook) formatExtraData(df *dataField) (result map[string]interf
 		if _, ok := hook.ignoreFields[k]; ok {
 			continue
 		}
+		ok, nested := rules.match(k)
+		if !ok {
+			continue
+		}
 
 		if fn, ok := hook.extraFilters[k]; ok {
 			v = fn(v) // apply custom filter
+		} else if fn, ok := hook.findTypeFormatter(v); ok {
+			v = fn(v) // apply custom formatter of the type
 		} else {
 			v = formatData(v) // use default formatter
 		}
+		if hook.NormalizeConfiguration.Enable {
+			v = hook.NormalizeConfiguration.normalize(v)
+		} else {
+			v = hook.NormalizeConfiguration.encode(v)
+		}
+		if !nested.isEmpty() {
+			if !hook.NormalizeConfiguration.Enable {
+				// nested patterns are matched against the normalized value
+				v = (&NormalizeConfiguration{}).normalize(v)
+			}
+			if v, ok = nested.filter(v); !ok {
+				continue
+			}
+		}
 		result[k] = v
 	}
 	return result
 }
 
+// findTypeFormatter returns the formatter of the value's type.
+// Exact types are looked up first, then interfaces in the order they were added.
+func (hook *SentryHook) findTypeFormatter(value interface{}) (func(interface{}) interface{}, bool) {
+	typ := reflect.TypeOf(value)
+	if typ == nil {
+		return nil, false
+	}
+	if fn, ok := hook.typeFilters[typ]; ok {
+		return fn, true
+	}
+	for _, f := range hook.ifaceFilters {
+		if typ.Implements(f.iface) {
+			return f.fn, true
+		}
+	}
+	return nil, false
+}
+
+type interfaceFilter struct {
+	iface reflect.Type
+	fn    func(interface{}) interface{}
+}
+
 // formatData returns value as a suitable format.
 func formatData(value interface{}) (formatted interface{}) {
 	switch value := value.(type) {
@@ -404,21 +740,3 @@ func formatData(value interface{}) (formatted interface{}) {
 		return value
 	}
 }
-
-// utility classes for breadcrumb support
-type Breadcrumbs struct {
-	Values []Value `json:"values"`
-}
-
-type Value struct {
-	Timestamp int64       `json:"timestamp"`
-	Type      string      `json:"type"`
-	Message   string      `json:"message"`
-	Category  string      `json:"category"`
-	Level     string      `json:"string"`
-	Data      interface{} `json:"data"`
-}
-
-func (b *Breadcrumbs) Class() string {
-	return "breadcrumbs"
-}
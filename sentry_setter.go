@@ -12,6 +12,10 @@ func (hook *SentryHook) SetDefaultLoggerName(name string) {
 // SetEnvironment sets environment tag.
 func (hook *SentryHook) SetEnvironment(environment string) {
 	hook.client.SetEnvironment(environment)
+	root := hook.root()
+	root.dscMu.Lock()
+	defer root.dscMu.Unlock()
+	root.environment = environment
 }
 
 // SetHttpContext sets http client.
@@ -29,6 +33,15 @@ func (hook *SentryHook) SetIncludePaths(p []string) {
 	hook.client.SetIncludePaths(p)
 }
 
+// SetPublicKey sets the public key of the DSN for the dynamic sampling context.
+// It's needed only for hooks created with a client whose DSN is not SENTRY_DSN.
+func (hook *SentryHook) SetPublicKey(key string) {
+	root := hook.root()
+	root.dscMu.Lock()
+	defer root.dscMu.Unlock()
+	root.publicKey = key
+}
+
 // SetRelease sets release tag.
 func (hook *SentryHook) SetRelease(release string) {
 	hook.client.SetRelease(release)
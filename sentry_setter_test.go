@@ -1,6 +1,7 @@
 package logrus_sentry
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -175,3 +176,29 @@ func TestSetServerName(t *testing.T) {
 		a.Equal(server_name, packet.ServerName, "server name must be set")
 	})
 }
+
+func TestSetEnvironmentConcurrently(t *testing.T) {
+	hook, err := NewSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	hook.TracingConfiguration.SampleRate = 0
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			hook.SetEnvironment(fmt.Sprint("env", i))
+			hook.SetPublicKey(fmt.Sprint("key", i))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_, tx := hook.StartTransaction(context.Background(), "GET /users")
+		tx.Finish()
+	}
+	<-done
+
+	_, tx := hook.StartTransaction(context.Background(), "GET /users")
+	assert.Equal(t, "env99", tx.tx.dsc["environment"])
+	assert.Equal(t, "key99", tx.tx.dsc["public_key"])
+}
@@ -37,8 +37,20 @@ func getTestLogger() *logrus.Logger {
 // so need to explicitly construct one for purpose of test
 type resultPacket struct {
 	raven.Packet
-	Stacktrace raven.Stacktrace `json:"stacktrace"`
-	Exception  raven.Exception  `json:"exception"`
+	Stacktrace  raven.Stacktrace `json:"stacktrace"`
+	Exception   raven.Exception  `json:"exception"`
+	Breadcrumbs Breadcrumbs      `json:"breadcrumbs"`
+	User        User             `json:"user"`
+	Contexts    Contexts         `json:"contexts"`
+
+	// raven.Timestamp of events, or float seconds of transactions
+	Timestamp json.RawMessage `json:"timestamp"`
+
+	// fields of transactions
+	Type           string                   `json:"type"`
+	Transaction    string                   `json:"transaction"`
+	StartTimestamp float64                  `json:"start_timestamp"`
+	Spans          []map[string]interface{} `json:"spans"`
 }
 
 func WithTestDSN(t *testing.T, tf func(string, <-chan *resultPacket)) {
@@ -227,6 +239,58 @@ func TestSentryFingerprint(t *testing.T) {
 	})
 }
 
+func TestBeforeSend(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		logger.Hooks.Add(hook)
+
+		hook.AddBeforeSend(func(entry *logrus.Entry, packet *raven.Packet) *raven.Packet {
+			if _, ok := entry.Data["drop"]; ok {
+				return nil
+			}
+			packet.Message = "modified: " + packet.Message
+			return packet
+		})
+		hook.AddBeforeSend(func(entry *logrus.Entry, packet *raven.Packet) *raven.Packet {
+			packet.Tags = append(packet.Tags, raven.Tag{Key: "before_send", Value: "true"})
+			return packet
+		})
+
+		logger.Error(message)
+		packet := <-pch
+		if expected := "modified: " + message; packet.Message != expected {
+			t.Errorf("message should have been %s, was %s", expected, packet.Message)
+		}
+		expectedTags := raven.Tags{{Key: "before_send", Value: "true"}}
+		if !reflect.DeepEqual(packet.Tags, expectedTags) {
+			t.Errorf("tags should have been %+v, was %+v", expectedTags, packet.Tags)
+		}
+
+		err = hook.Fire(&logrus.Entry{
+			Data:    logrus.Fields{"drop": true},
+			Level:   logrus.ErrorLevel,
+			Message: message,
+		})
+		if err != nil {
+			t.Errorf("dropped event should not be an error: %s", err)
+		}
+		select {
+		case <-pch:
+			t.Error("dropped event should not be sent")
+		default:
+		}
+		if dropped := hook.Stats().Dropped; dropped != 1 {
+			t.Errorf("dropped events should have been 1, was %d", dropped)
+		}
+	})
+}
+
 func TestAddIgnore(t *testing.T) {
 	hook := SentryHook{
 		ignoreFields: make(map[string]struct{}),
@@ -281,6 +345,51 @@ func TestAddExtraFilter(t *testing.T) {
 	}
 }
 
+func TestAddTypeFormatter(t *testing.T) {
+	a := assert.New(t)
+
+	hook := SentryHook{
+		ignoreFields: make(map[string]struct{}),
+		extraFilters: make(map[string]func(interface{}) interface{}),
+		typeFilters:  make(map[reflect.Type]func(interface{}) interface{}),
+	}
+	hook.AddTypeFormatter(myStringer{}, func(v interface{}) interface{} {
+		return "myStringer formatter"
+	})
+	hook.AddTypeFormatter((*error)(nil), func(v interface{}) interface{} {
+		return "error formatter"
+	})
+	hook.AddTypeFormatter((*fmt.Stringer)(nil), func(v interface{}) interface{} {
+		return "fmt.Stringer formatter"
+	})
+	hook.AddTypeFormatter(nil, nil)
+	hook.AddExtraFilter("filter1", func(v interface{}) interface{} {
+		return "filter1 value"
+	})
+
+	tests := []struct {
+		key      string
+		value    interface{}
+		expected interface{}
+	}{
+		{"myStringer", myStringer{}, "myStringer formatter"},
+		{"myStringer_ptr", &myStringer{}, "fmt.Stringer formatter"},
+		{"error", errors.New("this is a test error"), "error formatter"},
+		{"time_duration", time.Hour, "fmt.Stringer formatter"},
+		{"integer", 13, 13},
+		{"nil", nil, nil},
+		{"filter1", myStringer{}, "filter1 value"},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+
+		df := newDataField(logrus.Fields{tt.key: tt.value})
+		result := hook.formatExtraData(df)
+		a.Equal(tt.expected, result[tt.key], target)
+	}
+}
+
 func TestFormatExtraData(t *testing.T) {
 	hook := SentryHook{
 		ignoreFields: make(map[string]struct{}),
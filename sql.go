@@ -0,0 +1,310 @@
+package logrus_sentry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/getsentry/raven-go"
+)
+
+var (
+	sqlSpacePattern  = regexp.MustCompile(`\s+`)
+	sqlStringPattern = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumberPattern = regexp.MustCompile(`(^|[^\w$.:@?])-?\d+(?:\.\d+)?\b`)
+
+	errNamedParams = errors.New("sql: driver does not support the use of Named Parameters")
+	errTxOptions   = errors.New("sql: driver does not support non-default transaction options")
+)
+
+// SQLConfiguration allows for configuring the breadcrumbs of queries recorded
+// by the drivers wrapped by WrapDriver.
+type SQLConfiguration struct {
+	// whether the parameters of queries are recorded
+	IncludeParams bool
+	// whether the values of the recorded parameters are replaced with "[Filtered]"
+	RedactParams bool
+}
+
+// normalizeQuery collapses the whitespaces of a query, and replaces its
+// literals with "?" so that values in it are not recorded.
+func normalizeQuery(query string) string {
+	query = strings.TrimSpace(sqlSpacePattern.ReplaceAllString(query, " "))
+	query = sqlStringPattern.ReplaceAllString(query, "'?'")
+	return sqlNumberPattern.ReplaceAllString(query, "${1}?")
+}
+
+// WrapDriver returns a driver which records the queries of d as "query"
+// breadcrumbs into the breadcrumb buffer of the query context.
+// Breadcrumbs must be enabled on the hook.
+//
+//	sql.Register("postgres-sentry", hook.WrapDriver(&pq.Driver{}))
+func (hook *SentryHook) WrapDriver(d driver.Driver) driver.Driver {
+	wrapped := &sqlDriver{Driver: d, hook: hook}
+	if _, ok := d.(driver.DriverContext); ok {
+		return &sqlDriverContext{sqlDriver: wrapped}
+	}
+	return wrapped
+}
+
+func (hook *SentryHook) recordQuery(ctx context.Context, query string, args []driver.NamedValue, start time.Time, err error) {
+	conf := &hook.SQLConfiguration
+	data := map[string]interface{}{
+		"duration": time.Since(start).String(),
+	}
+	if conf.IncludeParams && len(args) != 0 {
+		params := make([]interface{}, len(args))
+		for i, arg := range args {
+			if conf.RedactParams {
+				params[i] = markerFiltered
+			} else {
+				params[i] = formatData(arg.Value)
+			}
+		}
+		data["params"] = params
+	}
+	level := raven.INFO
+	if err != nil && err != driver.ErrSkip {
+		level = raven.ERROR
+		data["error"] = err.Error()
+	}
+
+	hook.addBreadcrumb(ctx, Breadcrumb{
+		Timestamp: start,
+		Type:      "query",
+		Category:  "query",
+		Message:   normalizeQuery(query),
+		Level:     level,
+		Data:      data,
+	})
+}
+
+type sqlDriver struct {
+	driver.Driver
+	hook *SentryHook
+}
+
+func (d *sqlDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{Conn: conn, hook: d.hook}, nil
+}
+
+// sqlDriverContext is the driver of the drivers which implement
+// driver.DriverContext.
+type sqlDriverContext struct {
+	*sqlDriver
+}
+
+func (d *sqlDriverContext) OpenConnector(name string) (driver.Connector, error) {
+	connector, err := d.Driver.(driver.DriverContext).OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConnector{Connector: connector, driver: d}, nil
+}
+
+type sqlConnector struct {
+	driver.Connector
+	driver *sqlDriverContext
+}
+
+func (c *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{Conn: conn, hook: c.driver.hook}, nil
+}
+
+func (c *sqlConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c *sqlConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// validator is driver.Validator, which is added in Go 1.15.
+type validator interface {
+	IsValid() bool
+}
+
+type sqlConn struct {
+	driver.Conn
+	hook *SentryHook
+}
+
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStmt{Stmt: stmt, conn: c.Conn, query: query, hook: c.hook}, nil
+}
+
+func (c *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errTxOptions
+	}
+	return c.Conn.Begin()
+}
+
+func (c *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.hook.recordQuery(ctx, query, args, start, err)
+	}
+	return rows, err
+}
+
+func (c *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := e.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.hook.recordQuery(ctx, query, args, start, err)
+	}
+	return result, err
+}
+
+func (c *sqlConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *sqlConn) IsValid() bool {
+	if v, ok := c.Conn.(validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+type sqlStmt struct {
+	driver.Stmt
+	// the connection of the statement, whose NamedValueChecker is used when
+	// the statement doesn't have one
+	conn  driver.Conn
+	query string
+	hook  *SentryHook
+}
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (result driver.Result, err error) {
+	start := time.Now()
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = plainValues(args); err == nil {
+			result, err = s.Stmt.Exec(values)
+		}
+	}
+	s.hook.recordQuery(ctx, s.query, args, start, err)
+	return result, err
+}
+
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	start := time.Now()
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = plainValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	s.hook.recordQuery(ctx, s.query, args, start, err)
+	return rows, err
+}
+
+func (s *sqlStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if c, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return c.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+// CheckNamedValue is called instead of the one of the connection, as
+// database/sql prefers statements.
+func (s *sqlStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func plainValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errNamedParams
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,207 @@
+package logrus_sentry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDriver is a driver which supports only the methods required by
+// database/sql, so the queries go through prepared statements.
+type testDriver struct{}
+
+func (testDriver) Open(name string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(query string) (driver.Stmt, error) { return testStmt{query: query}, nil }
+func (testConn) Close() error                              { return nil }
+func (testConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type testStmt struct {
+	query string
+}
+
+func (testStmt) Close() error  { return nil }
+func (testStmt) NumInput() int { return -1 }
+
+func (s testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errors.New("syntax error")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (testStmt) Query(args []driver.Value) (driver.Rows, error) { return testRows{}, nil }
+
+type testRows struct{}
+
+func (testRows) Columns() []string              { return []string{"id"} }
+func (testRows) Close() error                   { return nil }
+func (testRows) Next(dest []driver.Value) error { return io.EOF }
+
+// testContextDriver is a driver which implements the optional interfaces.
+type testContextDriver struct {
+	testDriver
+	conn *testContextConn
+}
+
+func (d testContextDriver) OpenConnector(name string) (driver.Connector, error) {
+	return testConnector{d}, nil
+}
+
+type testConnector struct {
+	d testContextDriver
+}
+
+func (c testConnector) Connect(ctx context.Context) (driver.Conn, error) { return c.d.conn, nil }
+func (c testConnector) Driver() driver.Driver                            { return c.d }
+
+type testContextConn struct {
+	testConn
+	valid   bool
+	resets  int
+	checked []interface{}
+}
+
+func (c *testContextConn) IsValid() bool { return c.valid }
+
+func (c *testContextConn) ResetSession(ctx context.Context) error {
+	c.resets++
+	return nil
+}
+
+func (c *testContextConn) CheckNamedValue(nv *driver.NamedValue) error {
+	c.checked = append(c.checked, nv.Value)
+	if id, ok := nv.Value.(testID); ok {
+		nv.Value = id.id
+	}
+	return nil
+}
+
+// testID can't be converted by the default converter.
+type testID struct{ id int64 }
+
+var testDriverID int
+
+func openTestDB(hook *SentryHook) (*sql.DB, error) {
+	testDriverID++
+	name := fmt.Sprintf("sentry-test-%d", testDriverID)
+	sql.Register(name, hook.WrapDriver(testDriver{}))
+	return sql.Open(name, "")
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT * FROM users WHERE id = $1", "SELECT * FROM users WHERE id = $1"},
+		{"SELECT *\n\tFROM t1\n WHERE id = 13 AND name = 'it''s' ", "SELECT * FROM t1 WHERE id = ? AND name = '?'"},
+		{"UPDATE t SET price = -1.5 WHERE code = :code", "UPDATE t SET price = ? WHERE code = :code"},
+		{"SELECT 1", "SELECT ?"},
+	}
+
+	for _, tt := range tests {
+		target := fmt.Sprintf("%+v", tt)
+		a.Equal(tt.expected, normalizeQuery(tt.query), target)
+	}
+}
+
+func TestWrapDriver(t *testing.T) {
+	a := assert.New(t)
+
+	hook, err := NewSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	a.NoError(err)
+	hook.BreadcrumbConfiguration.Enable = true
+	hook.SQLConfiguration.IncludeParams = true
+
+	db, err := openTestDB(hook)
+	if !a.NoError(err) {
+		return
+	}
+	defer db.Close()
+
+	ctx := NewBreadcrumbContext(context.Background())
+	_, err = db.ExecContext(ctx, "UPDATE users SET name = ? WHERE id = 13", "alice")
+	a.NoError(err)
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users")
+	if a.NoError(err) {
+		rows.Close()
+	}
+	_, err = db.ExecContext(ctx, "fail")
+	a.Error(err)
+
+	values := hook.breadcrumbBufferFor(ctx).snapshot()
+	if !a.Len(values, 3) {
+		return
+	}
+
+	a.Equal("query", values[0].Type)
+	a.Equal("query", values[0].Category)
+	a.Equal(raven.INFO, values[0].Level)
+	a.Equal("UPDATE users SET name = ? WHERE id = ?", values[0].Message)
+	data := values[0].Data
+	a.Equal([]interface{}{markerFiltered}, data["params"])
+	a.NotEmpty(data["duration"])
+
+	a.Equal("SELECT id FROM users", values[1].Message)
+	a.NotContains(values[1].Data, "params")
+
+	a.Equal(raven.ERROR, values[2].Level)
+	a.Equal("syntax error", values[2].Data["error"])
+
+	hook.SQLConfiguration.RedactParams = false
+	_, err = db.Exec("DELETE FROM users WHERE name = ?", "bob")
+	a.NoError(err)
+	values = hook.breadcrumbs.snapshot()
+	if a.Len(values, 1) {
+		a.Equal([]interface{}{"bob"}, values[0].Data["params"])
+	}
+}
+
+func TestWrapDriverInterfaces(t *testing.T) {
+	a := assert.New(t)
+
+	hook, err := NewSentryHook("", []logrus.Level{logrus.ErrorLevel})
+	a.NoError(err)
+
+	conn := &testContextConn{valid: true}
+	wrapped := hook.WrapDriver(testContextDriver{conn: conn})
+	dc, ok := wrapped.(driver.DriverContext)
+	if !a.True(ok, "DriverContext should be forwarded") {
+		return
+	}
+	a.Implements((*driver.Driver)(nil), hook.WrapDriver(testDriver{}))
+	_, ok = hook.WrapDriver(testDriver{}).(driver.DriverContext)
+	a.False(ok, "DriverContext should not be added")
+
+	connector, err := dc.OpenConnector("")
+	a.NoError(err)
+	a.Equal(wrapped, connector.Driver())
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	_, err = db.Exec("UPDATE users SET name = 'alice' WHERE id = ?", testID{13})
+	a.NoError(err)
+	a.Contains(conn.checked, testID{13}, "NamedValueChecker should be forwarded")
+
+	c, err := connector.Connect(context.Background())
+	a.NoError(err)
+	a.True(c.(validator).IsValid())
+	conn.valid = false
+	a.False(c.(validator).IsValid(), "Validator should be forwarded")
+	a.NoError(c.(driver.SessionResetter).ResetSession(context.Background()))
+	a.Equal(1, conn.resets, "SessionResetter should be forwarded")
+}
@@ -0,0 +1,28 @@
+package logrus_sentry
+
+import "sync/atomic"
+
+// Stats is the statistics of the events handled by a hook.
+type Stats struct {
+	// the number of events dropped by event processors or BeforeSend callbacks
+	Dropped uint64
+}
+
+type stats struct {
+	dropped uint64
+}
+
+func (s *stats) addDropped() {
+	if s != nil {
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+func (s *stats) snapshot() Stats {
+	if s == nil {
+		return Stats{}
+	}
+	return Stats{
+		Dropped: atomic.LoadUint64(&s.dropped),
+	}
+}
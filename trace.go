@@ -0,0 +1,192 @@
+package logrus_sentry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/getsentry/raven-go"
+)
+
+const (
+	tagTraceID     = "trace_id"
+	tagSpanID      = "span_id"
+	extraTraceLink = "trace_link"
+
+	// the key of the dynamic sampling context in the "trace" context
+	traceKeyDSC = "dynamic_sampling_context"
+)
+
+// SpanContext identifies a span of a distributed trace, e.g. the span
+// context of OpenTelemetry.
+type SpanContext struct {
+	// 32 hex characters
+	TraceID string
+	// 16 hex characters
+	SpanID string
+	// the span ID of the parent span, which may be empty
+	ParentSpanID string
+	Sampled      bool
+}
+
+// IsValid reports whether it has a trace ID and span ID.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.SpanID != ""
+}
+
+// traceContext returns the "trace" context of sentry events.
+func (sc SpanContext) traceContext() map[string]interface{} {
+	c := map[string]interface{}{
+		"trace_id": sc.TraceID,
+		"span_id":  sc.SpanID,
+	}
+	if sc.ParentSpanID != "" {
+		c["parent_span_id"] = sc.ParentSpanID
+	}
+	return c
+}
+
+// TraceConfiguration allows for configuring the correlation of events with
+// the active span of entry contexts.
+type TraceConfiguration struct {
+	// whether the active span is attached to events
+	Enable bool
+	// returns the active span of a context. With OpenTelemetry:
+	//
+	//	SpanContextFuncOf(trace.SpanContextFromContext)
+	SpanContextFunc func(ctx context.Context) (SpanContext, bool)
+	// whether the trace ID and span ID are added as tags
+	Tags bool
+	// the link to the trace added as the extra data "trace_link".
+	// "{trace_id}" and "{span_id}" are replaced with the IDs of the span,
+	// e.g. "https://jaeger.example.com/trace/{trace_id}"
+	LinkTemplate string
+}
+
+// spanContext returns the active span of ctx. The spans started by
+// StartTransaction and StartSpan take precedence over the trace continued by
+// ContinueTrace, which takes precedence over SpanContextFunc.
+func (conf *TraceConfiguration) spanContext(ctx context.Context) (SpanContext, bool) {
+	if span, ok := SpanFromContext(ctx); ok {
+		return span.SpanContext(), true
+	}
+	if p, ok := propagationFromContext(ctx); ok {
+		return p.span, true
+	}
+	if ctx == nil || conf.SpanContextFunc == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := conf.SpanContextFunc(ctx)
+	return sc, ok && sc.IsValid()
+}
+
+var (
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// SpanContextFuncOf returns a SpanContextFunc calling fn, which returns the
+// span context of a context in the type of a tracing library, e.g.
+// trace.SpanContextFromContext of OpenTelemetry. The type must have the
+// methods TraceID and SpanID, which return the hex IDs as strings or
+// fmt.Stringers, and IsSampled, which returns bool.
+// It panics when fn is not such a function.
+func SpanContextFuncOf(fn interface{}) func(ctx context.Context) (SpanContext, bool) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.Type().NumIn() != 1 || !contextType.AssignableTo(v.Type().In(0)) || v.Type().NumOut() != 1 {
+		panic(fmt.Sprintf("logrus_sentry: %T is not a function returning the span context of a context", fn))
+	}
+	t := v.Type()
+	traceID, ok1 := spanIDMethod(t.Out(0), "TraceID")
+	spanID, ok2 := spanIDMethod(t.Out(0), "SpanID")
+	sampled, ok3 := t.Out(0).MethodByName("IsSampled")
+	if !ok1 || !ok2 || !ok3 || sampled.Type.NumIn() != 1 || sampled.Type.NumOut() != 1 || sampled.Type.Out(0).Kind() != reflect.Bool {
+		panic(fmt.Sprintf("logrus_sentry: %s has no TraceID, SpanID and IsSampled methods", t.Out(0)))
+	}
+
+	return func(ctx context.Context) (SpanContext, bool) {
+		out := v.Call([]reflect.Value{reflect.ValueOf(&ctx).Elem()})[0]
+		if out.Kind() == reflect.Ptr && out.IsNil() {
+			return SpanContext{}, false
+		}
+		sc := SpanContext{
+			TraceID: traceID(out),
+			SpanID:  spanID(out),
+			Sampled: out.Method(sampled.Index).Call(nil)[0].Bool(),
+		}
+		// the IDs of invalid spans are zeros
+		return sc, strings.Trim(sc.TraceID, "0") != "" && strings.Trim(sc.SpanID, "0") != ""
+	}
+}
+
+// spanIDMethod returns a function calling the method of t, which returns an ID
+// as a string or fmt.Stringer.
+func spanIDMethod(t reflect.Type, name string) (func(reflect.Value) string, bool) {
+	m, ok := t.MethodByName(name)
+	// the receiver is the first argument
+	if !ok || m.Type.NumIn() != 1 || m.Type.NumOut() != 1 {
+		return nil, false
+	}
+	out := m.Type.Out(0)
+	switch {
+	case out.Kind() == reflect.String:
+		return func(v reflect.Value) string {
+			return v.Method(m.Index).Call(nil)[0].String()
+		}, true
+	case out.Implements(stringerType):
+		return func(v reflect.Value) string {
+			return v.Method(m.Index).Call(nil)[0].Interface().(fmt.Stringer).String()
+		}, true
+	}
+	return nil, false
+}
+
+func (conf *TraceConfiguration) link(sc SpanContext) string {
+	r := strings.NewReplacer("{trace_id}", sc.TraceID, "{span_id}", sc.SpanID)
+	return r.Replace(conf.LinkTemplate)
+}
+
+// applyTrace attaches the span to the packet.
+func (conf *TraceConfiguration) applyTrace(packet *raven.Packet, sc SpanContext) {
+	contexts := packetContexts(packet)
+	if _, ok := contexts["trace"]; !ok {
+		contexts["trace"] = sc.traceContext()
+	}
+
+	if conf.Tags {
+		if !hasTag(packet.Tags, tagTraceID) {
+			packet.Tags = append(packet.Tags, raven.Tag{Key: tagTraceID, Value: sc.TraceID})
+		}
+		if !hasTag(packet.Tags, tagSpanID) {
+			packet.Tags = append(packet.Tags, raven.Tag{Key: tagSpanID, Value: sc.SpanID})
+		}
+	}
+
+	if conf.LinkTemplate != "" {
+		if packet.Extra == nil {
+			packet.Extra = make(map[string]interface{})
+		}
+		packet.Extra[extraTraceLink] = conf.link(sc)
+	}
+}
+
+func processTrace(ev *Event) *Event {
+	conf := &ev.hook.TraceConfiguration
+	if !conf.Enable {
+		return ev
+	}
+	if sc, ok := conf.spanContext(ev.Entry.Context); ok {
+		conf.applyTrace(ev.Packet, sc)
+		contexts := packetContexts(ev.Packet)
+		if trace, ok := contexts["trace"].(map[string]interface{}); ok {
+			if _, ok := trace[traceKeyDSC]; !ok {
+				// the context may be the one of a scope
+				trace = copyMap(trace)
+				trace[traceKeyDSC] = copyStringMap(ev.hook.dynamicSamplingContext(ev.Entry.Context, sc))
+				contexts["trace"] = trace
+			}
+		}
+	}
+	return ev
+}
@@ -0,0 +1,135 @@
+package logrus_sentry
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+type testSpanKey struct{}
+
+func testSpanContextFunc(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(testSpanKey{}).(SpanContext)
+	return sc, ok
+}
+
+func TestTraceConfiguration(t *testing.T) {
+	WithTestDSN(t, func(dsn string, pch <-chan *resultPacket) {
+		logger := getTestLogger()
+		hook, err := NewSentryHook(dsn, []logrus.Level{
+			logrus.ErrorLevel,
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		hook.TraceConfiguration.SpanContextFunc = testSpanContextFunc
+		hook.TraceConfiguration.LinkTemplate = "https://jaeger.example.com/trace/{trace_id}?span={span_id}"
+		logger.Hooks.Add(hook)
+
+		ctx := context.WithValue(context.Background(), testSpanKey{}, SpanContext{
+			TraceID:      testTraceID,
+			SpanID:       testSpanID,
+			ParentSpanID: "b7ad6b7169203331",
+		})
+		logger.WithContext(ctx).Error("failed")
+
+		packet := <-pch
+		assert.Equal(t, map[string]interface{}{
+			"trace_id":       testTraceID,
+			"span_id":        testSpanID,
+			"parent_span_id": "b7ad6b7169203331",
+			"dynamic_sampling_context": map[string]interface{}{
+				"trace_id":   testTraceID,
+				"public_key": "public",
+			},
+		}, packet.Contexts["trace"])
+		assert.Equal(t, raven.Tags{
+			{Key: "trace_id", Value: testTraceID},
+			{Key: "span_id", Value: testSpanID},
+		}, packet.Tags)
+		assert.Equal(t, "https://jaeger.example.com/trace/"+testTraceID+"?span="+testSpanID, packet.Extra["trace_link"])
+
+		// invalid span contexts are ignored
+		ctx = context.WithValue(context.Background(), testSpanKey{}, SpanContext{TraceID: testTraceID})
+		logger.WithContext(ctx).Error("failed")
+		packet = <-pch
+		assert.Nil(t, packet.Contexts)
+		assert.Empty(t, packet.Tags)
+	})
+}
+
+func TestTraceTags(t *testing.T) {
+	a := assert.New(t)
+
+	conf := &TraceConfiguration{Enable: true}
+	sc := SpanContext{TraceID: testTraceID, SpanID: testSpanID}
+
+	packet := raven.NewPacket(message)
+	conf.applyTrace(packet, sc)
+	a.Empty(packet.Tags)
+	a.Nil(packet.Extra["trace_link"])
+
+	conf.Tags = true
+	packet = raven.NewPacket(message, Contexts{"trace": "custom"})
+	packet.Tags = raven.Tags{{Key: "trace_id", Value: "custom"}}
+	conf.applyTrace(packet, sc)
+	a.Equal(raven.Tags{{Key: "trace_id", Value: "custom"}, {Key: "span_id", Value: testSpanID}}, packet.Tags)
+	a.Equal(Contexts{"trace": "custom"}, packet.Interfaces[0])
+}
+
+// testOTelTraceID and testOTelSpanContext have the shape of the types of
+// OpenTelemetry.
+type testOTelTraceID [16]byte
+
+func (id testOTelTraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type testOTelSpanContext struct {
+	traceID testOTelTraceID
+	spanID  string
+	sampled bool
+}
+
+func (sc testOTelSpanContext) TraceID() testOTelTraceID { return sc.traceID }
+func (sc testOTelSpanContext) SpanID() string           { return sc.spanID }
+func (sc testOTelSpanContext) IsSampled() bool          { return sc.sampled }
+
+func testOTelSpanContextFromContext(ctx context.Context) testOTelSpanContext {
+	sc, _ := ctx.Value(testSpanKey{}).(testOTelSpanContext)
+	return sc
+}
+
+func TestSpanContextFuncOf(t *testing.T) {
+	a := assert.New(t)
+
+	var traceID testOTelTraceID
+	b, _ := hex.DecodeString(testTraceID)
+	copy(traceID[:], b)
+
+	fn := SpanContextFuncOf(testOTelSpanContextFromContext)
+	ctx := context.WithValue(context.Background(), testSpanKey{}, testOTelSpanContext{
+		traceID: traceID,
+		spanID:  testSpanID,
+		sampled: true,
+	})
+	sc, ok := fn(ctx)
+	a.True(ok)
+	a.Equal(SpanContext{TraceID: testTraceID, SpanID: testSpanID, Sampled: true}, sc)
+
+	_, ok = fn(context.Background())
+	a.False(ok, "spans whose IDs are zeros should be ignored")
+
+	a.Panics(func() { SpanContextFuncOf(func(ctx context.Context) string { return "" }) })
+	a.Panics(func() { SpanContextFuncOf(testSpanContextFunc) })
+	a.Panics(func() { SpanContextFuncOf(nil) })
+}
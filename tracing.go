@@ -0,0 +1,388 @@
+package logrus_sentry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	mrand "math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/getsentry/raven-go"
+	"github.com/sirupsen/logrus"
+)
+
+type spanKey struct{}
+
+// TracingConfiguration allows for configuring the transactions started by
+// StartTransaction.
+type TracingConfiguration struct {
+	// the rate of transactions sent to sentry, from 0 to 1
+	SampleRate float64
+	// the maximum number of spans sent with a transaction. later ones are
+	// dropped
+	MaxSpans int
+}
+
+// Span is a span of a transaction, which measures the duration of an
+// operation. Its methods are safe for concurrent use, and do nothing for nil.
+type Span struct {
+	traceID      string
+	spanID       string
+	parentSpanID string
+	op           string
+	sampled      bool
+	start        time.Time
+
+	mu          sync.Mutex
+	description string
+	status      string
+	tags        map[string]string
+	data        map[string]interface{}
+	end         time.Time
+
+	tx *transaction
+}
+
+// transaction records the finished spans of a transaction.
+type transaction struct {
+	hook *SentryHook
+	name string
+	root *Span
+	// the dynamic sampling context of the trace, which is propagated by
+	// baggage headers
+	dsc map[string]string
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// packetField is an interface adding a top-level field to the packet.
+type packetField struct {
+	key   string
+	value interface{}
+}
+
+// Class returns the key of the field.
+func (f packetField) Class() string {
+	return f.key
+}
+
+// MarshalJSON encodes the value of the field.
+func (f packetField) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.value)
+}
+
+// Spans is the interface of the spans of a transaction.
+type Spans []map[string]interface{}
+
+// Class returns the key of spans in the packet.
+func (s Spans) Class() string {
+	return "spans"
+}
+
+// mapDescriptions returns a copy of the spans whose descriptions are
+// replaced with fn.
+func (s Spans) mapDescriptions(fn func(string) string) Spans {
+	result := make(Spans, len(s))
+	for i, span := range s {
+		span = copyMap(span)
+		if d, ok := span["description"].(string); ok {
+			span["description"] = fn(d)
+		}
+		result[i] = span
+	}
+	return result
+}
+
+// StartTransaction starts a transaction, and returns a copy of ctx which has
+// it as the current span. The transaction is sent when it's finished.
+// It continues the trace of the span in ctx if any. The trace continued by
+// ContinueTrace is sampled by SampleRate unless its headers decide it.
+func (hook *SentryHook) StartTransaction(ctx context.Context, name string) (context.Context, *Span) {
+	s := &Span{
+		op:    "transaction",
+		start: time.Now(),
+	}
+	_, own := SpanFromContext(ctx)
+	if p, ok := propagationFromContext(ctx); ok && !own {
+		// the transaction is the span of this service in the continued trace
+		s.traceID = p.span.TraceID
+		s.spanID = p.span.SpanID
+		s.parentSpanID = p.span.ParentSpanID
+	} else if parent, ok := hook.TraceConfiguration.spanContext(ctx); ok {
+		s.traceID = parent.TraceID
+		s.spanID = newSpanID()
+		s.parentSpanID = parent.SpanID
+	} else {
+		s.traceID = newTraceID()
+		s.spanID = newSpanID()
+	}
+	sampled, decided := hook.sampled(ctx)
+	if !decided {
+		sampled = mrand.Float64() < hook.TracingConfiguration.SampleRate
+	}
+	s.sampled = sampled
+	s.tx = &transaction{
+		hook: hook,
+		name: name,
+		root: s,
+	}
+	if dsc, ok := traceDynamicSamplingContext(ctx, s.traceID); ok {
+		s.tx.dsc = dsc
+	} else {
+		// this service is the head of the trace
+		s.tx.dsc = hook.newDynamicSamplingContext(s.traceID, s.tx)
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// StartSpan starts a child span of the current span of ctx, and returns a copy
+// of ctx which has it as the current span. It returns nil as the span when ctx
+// has no span.
+func StartSpan(ctx context.Context, op string) (context.Context, *Span) {
+	parent, ok := SpanFromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	s := &Span{
+		traceID:      parent.traceID,
+		spanID:       newSpanID(),
+		parentSpanID: parent.spanID,
+		op:           op,
+		sampled:      parent.sampled,
+		start:        time.Now(),
+		tx:           parent.tx,
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// SpanFromContext returns the current span of ctx.
+func SpanFromContext(ctx context.Context) (*Span, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	s, ok := ctx.Value(spanKey{}).(*Span)
+	return s, ok && s != nil
+}
+
+// SpanContext returns the IDs of the span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{
+		TraceID:      s.traceID,
+		SpanID:       s.spanID,
+		ParentSpanID: s.parentSpanID,
+		Sampled:      s.sampled,
+	}
+}
+
+// SetDescription sets the description of the span, e.g. the query.
+func (s *Span) SetDescription(description string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.description = description
+}
+
+// SetStatus sets the status of the span, e.g. "ok" or "internal_error".
+func (s *Span) SetStatus(status string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// SetTag sets a tag of the span.
+func (s *Span) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tags == nil {
+		s.tags = make(map[string]string)
+	}
+	s.tags[key] = value
+}
+
+// SetData sets a value of the data of the span.
+func (s *Span) SetData(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		s.data = make(map[string]interface{})
+	}
+	s.data[key] = value
+}
+
+// Finish ends the span. The transaction is sent with its finished spans when
+// its root span is finished.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if !s.sampled {
+		return
+	}
+	if s == s.tx.root {
+		s.tx.send()
+		return
+	}
+	s.tx.record(s)
+}
+
+// toJSON returns the span in the format of sentry.
+func (s *Span) toJSON() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.traceContext()
+	v["start_timestamp"] = unixSeconds(s.start)
+	v["timestamp"] = unixSeconds(s.end)
+	if s.description != "" {
+		v["description"] = s.description
+	}
+	if len(s.tags) != 0 {
+		v["tags"] = copyStringMap(s.tags)
+	}
+	if len(s.data) != 0 {
+		v["data"] = copyMap(s.data)
+	}
+	return v
+}
+
+// traceContext returns the "trace" context of the span. s.mu must be locked.
+func (s *Span) traceContext() map[string]interface{} {
+	c := s.SpanContext().traceContext()
+	c["op"] = s.op
+	if s.status != "" {
+		c["status"] = s.status
+	}
+	return c
+}
+
+func (tx *transaction) record(s *Span) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if len(tx.spans) < tx.hook.TracingConfiguration.MaxSpans {
+		tx.spans = append(tx.spans, s)
+	}
+}
+
+// packet returns the transaction event.
+func (tx *transaction) packet() *raven.Packet {
+	tx.mu.Lock()
+	spans := make(Spans, len(tx.spans))
+	for i, s := range tx.spans {
+		spans[i] = s.toJSON()
+	}
+	tx.mu.Unlock()
+
+	root := tx.root
+	root.mu.Lock()
+	defer root.mu.Unlock()
+
+	trace := root.traceContext()
+	if len(tx.dsc) != 0 {
+		trace[traceKeyDSC] = copyStringMap(tx.dsc)
+	}
+	packet := raven.NewPacket("",
+		packetField{key: "type", value: "transaction"},
+		packetField{key: "transaction", value: tx.name},
+		packetField{key: "start_timestamp", value: unixSeconds(root.start)},
+		// raven truncates the timestamp of packets to centiseconds, which may
+		// be before start_timestamp
+		packetField{key: "timestamp", value: unixSeconds(root.end)},
+		spans,
+		Contexts{"trace": trace},
+	)
+	packet.Timestamp = raven.Timestamp(ceilCentiseconds(root.end))
+	packet.Level = raven.INFO
+	packet.Platform = "go"
+	packet.ServerName = tx.hook.serverName
+
+	packet.Tags, _ = toTags(root.tags)
+	if len(root.data) != 0 {
+		packet.Extra = copyMap(root.data)
+	}
+	return packet
+}
+
+// send sends the transaction through the event processor pipeline and the
+// BeforeSend callbacks of the hook, the same as events.
+func (tx *transaction) send() {
+	hook := tx.hook
+	root := hook.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+
+	packet := tx.packet()
+	entry := &logrus.Entry{
+		Data:    logrus.Fields{},
+		Time:    time.Time(packet.Timestamp),
+		Level:   logrus.InfoLevel,
+		Message: tx.name,
+	}
+	hook.send(&Event{
+		Entry:       entry,
+		Packet:      packet,
+		hook:        hook,
+		df:          newDataField(entry.Data),
+		transaction: true,
+	})
+}
+
+// ceilCentiseconds rounds t up to centiseconds, so the timestamp of raven is
+// not before t.
+func ceilCentiseconds(t time.Time) time.Time {
+	const centisecond = 10 * time.Millisecond
+	if rounded := t.Truncate(centisecond); rounded.Before(t) {
+		return rounded.Add(centisecond)
+	}
+	return t
+}
+
+func newTraceID() string {
+	return randomHex(16)
+}
+
+func newSpanID() string {
+	return randomHex(8)
+}
+
+// fallbackRand generates IDs when crypto/rand fails. It's seeded with the
+// time and the pid, so processes don't generate the same IDs.
+var (
+	fallbackRandMu sync.Mutex
+	fallbackRand   = mrand.New(mrand.NewSource(time.Now().UnixNano() ^ int64(os.Getpid())<<32))
+)
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		fallbackRandMu.Lock()
+		fallbackRand.Read(b)
+		fallbackRandMu.Unlock()
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,127 @@
+package logrus_sentry
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/getsentry/raven-go"
+)
+
+var urlPattern = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.\-]*://[^\s"'<>]+`)
+
+var defaultSensitiveParams = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"auth",
+	"client_secret",
+	"key",
+	"password",
+	"secret",
+	"sig",
+	"signature",
+	"token",
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+	"X-Amz-Signature",
+}
+
+// URLConfiguration allows for configuring the redaction of credentials in URLs.
+// It is applied to the http request, and the URLs in the message, culprit
+// and exception of events.
+type URLConfiguration struct {
+	// whether credentials in URLs should be redacted
+	Enable bool
+	// the query parameters whose values are redacted, matched case-insensitively
+	SensitiveParams []string
+}
+
+func (conf *URLConfiguration) redactPacket(packet *raven.Packet) {
+	packet.Message = conf.redactURLs(packet.Message)
+	packet.Culprit = conf.redactURLs(packet.Culprit)
+
+	for i, inter := range packet.Interfaces {
+		switch v := inter.(type) {
+		case *raven.Http:
+			req := *v
+			req.URL = conf.redactURL(req.URL)
+			req.Query = conf.redactQuery(req.Query)
+			packet.Interfaces[i] = &req
+		case *raven.Exception:
+			exc := *v
+			exc.Value = conf.redactURLs(exc.Value)
+			packet.Interfaces[i] = &exc
+		case Spans:
+			packet.Interfaces[i] = v.mapDescriptions(conf.redactURLs)
+		}
+	}
+}
+
+// redactURLs redacts the credentials of all the URLs in s.
+func (conf *URLConfiguration) redactURLs(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	return urlPattern.ReplaceAllStringFunc(s, conf.redactURL)
+}
+
+// redactURL redacts the password of userinfo and sensitive query parameters.
+// The rest of the URL is kept as it is. The URL is not parsed, so malformed
+// URLs are redacted as well.
+func (conf *URLConfiguration) redactURL(rawURL string) string {
+	// scheme://userinfo@host/path?query#fragment
+	rest, fragment := rawURL, ""
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest, fragment = rest[:i], rest[i:]
+	}
+	query := ""
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest, query = rest[:i], rest[i+1:]
+	}
+
+	if i := strings.Index(rest, "://"); i >= 0 {
+		authority := rest[i+3:]
+		if j := strings.Index(authority, "/"); j >= 0 {
+			authority = authority[:j]
+		}
+		if at := strings.LastIndex(authority, "@"); at >= 0 {
+			userinfo := authority[:at]
+			redacted := markerFiltered
+			if colon := strings.Index(userinfo, ":"); colon >= 0 {
+				// keep the user name for debugging
+				redacted = userinfo[:colon+1] + markerFiltered
+			}
+			rest = rest[:i+3] + redacted + rest[i+3+at:]
+		}
+	}
+
+	if query != "" {
+		rest += "?" + conf.redactQuery(query)
+	} else if strings.Contains(rawURL, "?") {
+		rest += "?"
+	}
+	return rest + fragment
+}
+
+// redactQuery redacts the values of sensitive parameters in a raw query.
+func (conf *URLConfiguration) redactQuery(query string) string {
+	if query == "" {
+		return query
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		eq := strings.Index(param, "=")
+		if eq < 0 {
+			continue
+		}
+		name, err := url.QueryUnescape(param[:eq])
+		if err != nil {
+			name = param[:eq]
+		}
+		if containsFold(conf.SensitiveParams, name) {
+			params[i] = param[:eq+1] + markerFiltered
+		}
+	}
+	return strings.Join(params, "&")
+}
@@ -0,0 +1,70 @@
+package logrus_sentry
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/getsentry/raven-go"
+)
+
+// The SentryUser interface allows any type to be used as the user field.
+// The returned data is sent as additional user attributes.
+type SentryUser interface {
+	GetSentryUser() (id, username, email, ip, segment string, data map[string]interface{})
+}
+
+// User is the user interface of an event.
+// It extends raven.User with the attributes which raven.User doesn't have.
+type User struct {
+	raven.User
+	Segment string                 `json:"segment,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// newUserFromSentryUser returns the user of u. It returns false for nil
+// pointers, whose methods may panic, e.g. the methods of value receivers.
+func newUserFromSentryUser(u SentryUser) (*User, bool) {
+	if v := reflect.ValueOf(u); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+	id, username, email, ip, segment, data := u.GetSentryUser()
+	return &User{
+		User: raven.User{
+			ID:       id,
+			Username: username,
+			Email:    email,
+			IP:       ip,
+		},
+		Segment: segment,
+		Data:    data,
+	}, true
+}
+
+// formatUserID returns user id as a string, numeric ids are supported.
+func formatUserID(v interface{}) (string, bool) {
+	switch id := v.(type) {
+	case string:
+		return id, true
+	case int:
+		return strconv.Itoa(id), true
+	case int8:
+		return strconv.FormatInt(int64(id), 10), true
+	case int16:
+		return strconv.FormatInt(int64(id), 10), true
+	case int32:
+		return strconv.FormatInt(int64(id), 10), true
+	case int64:
+		return strconv.FormatInt(id, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(id), 10), true
+	case uint8:
+		return strconv.FormatUint(uint64(id), 10), true
+	case uint16:
+		return strconv.FormatUint(uint64(id), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(id), 10), true
+	case uint64:
+		return strconv.FormatUint(id, 10), true
+	}
+	return "", false
+}